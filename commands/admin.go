@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twitchgo/types"
+)
+
+func JoinChannel(ctx *ChatContext, args []string) error {
+	name := NormalizeChannel(args[0])
+	if !isAlphanumeric(name) {
+		return ErrUsage
+	}
+
+	joiner, ok := ctx.Sender.(types.ChannelJoiner)
+	if !ok {
+		return fmt.Errorf("transport cannot join channels")
+	}
+
+	if GetChannel(name) != nil {
+		ctx.Say(fmt.Sprintf("[Canais] @%s Já estou em #%s.", ctx.User().DisplayName, name))
+		return nil
+	}
+
+	config, err := ConfigFor(name)
+	if err != nil {
+		ctx.Say(fmt.Sprintf("[Canais] @%s Não consegui entrar em #%s.", ctx.User().DisplayName, name))
+		return err
+	}
+
+	if _, err := AddChannel(config); err != nil {
+		ctx.Say(fmt.Sprintf("[Canais] @%s Não consegui entrar em #%s.", ctx.User().DisplayName, name))
+		return err
+	}
+
+	joiner.Join(name)
+	ctx.Say(fmt.Sprintf("[Canais] @%s Entrando em #%s.", ctx.User().DisplayName, name))
+	return nil
+}
+
+func PartChannel(ctx *ChatContext, args []string) error {
+	name := ctx.Channel.Name
+	if len(args) > 0 {
+		name = NormalizeChannel(args[0])
+	}
+
+	joiner, ok := ctx.Sender.(types.ChannelJoiner)
+	if !ok {
+		return fmt.Errorf("transport cannot leave channels")
+	}
+
+	if GetChannel(name) == nil {
+		ctx.Say(fmt.Sprintf("[Canais] @%s Não estou em #%s.", ctx.User().DisplayName, name))
+		return nil
+	}
+
+	ctx.Say(fmt.Sprintf("[Canais] @%s Saindo de #%s.", ctx.User().DisplayName, name))
+	joiner.Depart(name)
+	return RemoveChannel(name)
+}
+
+func ListChannels(ctx *ChatContext, args []string) error {
+	ctx.Say(fmt.Sprintf("[Canais] %s", strings.Join(ChannelNames(), ", ")))
+	return nil
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJoinAndPartChannels(t *testing.T) {
+	withPermissions(t, PermissionConfig{Admins: []string{"chefe"}})
+	ch := newTestChannel(t, nil)
+	t.Cleanup(func() {
+		if GetChannel("outrocanal") != nil {
+			RemoveChannel("outrocanal")
+		}
+	})
+
+	ch.expect(t, "chefe", "#entrar #OutroCanal", "[Canais] @chefe Entrando em #outrocanal.")
+	if !ch.chat.Joined("outrocanal") || GetChannel("outrocanal") == nil {
+		t.Fatal("#entrar did not join outrocanal")
+	}
+	ch.expect(t, "chefe", "#entrar outrocanal", "Já estou em #outrocanal.")
+	ch.expect(t, "chefe", "#entrar outro-canal", "Uso: #entrar <canal>")
+	ch.expect(t, "chefe", "#canais", "[Canais] outrocanal, "+ch.Name)
+
+	ch.expect(t, "chefe", "#sair outrocanal", "[Canais] @chefe Saindo de #outrocanal.")
+	if ch.chat.Joined("outrocanal") || GetChannel("outrocanal") != nil {
+		t.Fatal("#sair did not leave outrocanal")
+	}
+	ch.expect(t, "chefe", "#sair outrocanal", "Não estou em #outrocanal.")
+	ch.expect(t, "chefe", "#canais", "[Canais] "+ch.Name)
+
+	// The joined channel kept its points next to the test channel's.
+	if _, err := os.Stat(filepath.Join(ch.env.Dir, "outrocanal", "user_data.json")); err != nil {
+		t.Fatalf("outrocanal's points were not saved in the data directory: %v", err)
+	}
+}
+
+func TestChannelCommandsNeedAdmin(t *testing.T) {
+	withPermissions(t, PermissionConfig{})
+	ch := newTestChannel(t, nil)
+
+	ch.expectSilence(t, "dono", "#entrar outrocanal", "broadcaster")
+	ch.expectSilence(t, "dono", "#sair", "broadcaster")
+	ch.expectSilence(t, "mod", "#canais", "moderator")
+	if GetChannel("outrocanal") != nil || GetChannel(ch.Name) == nil {
+		t.Fatal("channel list changed without an admin")
+	}
+}
@@ -0,0 +1,253 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"twitchgo/service"
+	"twitchgo/types"
+	"twitchgo/utils"
+)
+
+type ChannelConfig struct {
+	Name     string                    `yaml:"-"`
+	Prefix   string                    `yaml:"prefix"`
+	Commands []string                  `yaml:"commands"` // empty enables every command
+	Points   utils.PointsConfig        `yaml:"points"`
+	Trivia   service.TriviaConfig      `yaml:"trivia"`
+	Scramble service.ScrambleConfig    `yaml:"scramble"`
+	Games    service.CoordinatorConfig `yaml:"games"`
+	Daily    types.DailyConfig         `yaml:"daily"`
+	Roulette RouletteConfig            `yaml:"roulette"`
+	Clock    types.Clock               `yaml:"-"`
+}
+
+func DefaultChannelConfig() ChannelConfig {
+	return ChannelConfig{
+		Prefix:   "#",
+		Points:   utils.DefaultPointsConfig(),
+		Trivia:   DefaultTriviaConfig(),
+		Scramble: DefaultScrambleConfig(),
+		Games:    DefaultGamesConfig(),
+		Daily:    DefaultDailyConfig(),
+		Roulette: DefaultRouletteConfig(),
+		Clock:    utils.SystemClock,
+	}
+}
+
+// ForChannel returns a copy of the config for channel name, with its data
+// files kept in a directory of their own.
+func (c ChannelConfig) ForChannel(name string) ChannelConfig {
+	c.Name = NormalizeChannel(name)
+	c.Points = c.Points.ForChannel(c.Name)
+	c.Commands = append([]string(nil), c.Commands...)
+	return c
+}
+
+type Channel struct {
+	Name      string
+	Prefix    string
+	enabled   map[string]bool
+	points    types.PointsDatabase
+	rewards   *service.RewardsService
+	trivia    *service.TriviaManager
+	scramble  *service.ScrambleManager
+	games     *service.Coordinator
+	daily     types.DailyConfig
+	roulette  RouletteConfig
+	clock     types.Clock
+	cooldowns *utils.Cooldowns
+}
+
+func (ch *Channel) CommandEnabled(name string) bool {
+	return ch.enabled == nil || ch.enabled[strings.ToLower(name)]
+}
+
+type channelRegistry struct {
+	mutex    sync.RWMutex
+	channels map[string]*Channel
+	defaults ChannelConfig
+	// configFor, when set, builds the config of channels joined at runtime.
+	configFor func(name string) (ChannelConfig, error)
+}
+
+var channels = &channelRegistry{
+	channels: make(map[string]*Channel),
+	defaults: DefaultChannelConfig(),
+}
+
+var (
+	triviaDB   types.TriviaDatabase
+	scrambleDB types.ScrambleDatabase
+)
+
+// Setup loads the databases shared by every channel and sets the config used
+// for channels joined at runtime. It must be called before AddChannel.
+func Setup(defaults ChannelConfig) {
+	triviaDB = utils.NewInMemoryTriviaDB()
+	scrambleDB = utils.NewInMemoryScrambleDB()
+
+	channels.mutex.Lock()
+	channels.defaults = defaults
+	channels.mutex.Unlock()
+}
+
+// ConfigureChannels makes channels joined at runtime take their config from
+// configFor rather than from the defaults given to Setup, so they get the
+// same overrides as the channels joined at startup.
+func ConfigureChannels(configFor func(name string) (ChannelConfig, error)) {
+	channels.mutex.Lock()
+	channels.configFor = configFor
+	channels.mutex.Unlock()
+}
+
+// ConfigFor returns the config for a channel joined at runtime.
+func ConfigFor(name string) (ChannelConfig, error) {
+	channels.mutex.RLock()
+	defaults, configFor := channels.defaults, channels.configFor
+	channels.mutex.RUnlock()
+
+	if configFor != nil {
+		return configFor(name)
+	}
+	return defaults.ForChannel(name), nil
+}
+
+func AddChannel(config ChannelConfig) (*Channel, error) {
+	name := NormalizeChannel(config.Name)
+	if name == "" {
+		return nil, fmt.Errorf("channel name is empty")
+	}
+
+	channels.mutex.Lock()
+	defer channels.mutex.Unlock()
+
+	if _, exists := channels.channels[name]; exists {
+		return nil, fmt.Errorf("channel %s already joined", name)
+	}
+
+	points, err := utils.NewPointsDatabase(config.Points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open points for %s: %w", name, err)
+	}
+
+	clock := config.Clock
+	if clock == nil {
+		clock = utils.SystemClock
+	}
+
+	rewards := service.NewRewardsService(points)
+	ch := &Channel{
+		Name:      name,
+		Prefix:    config.Prefix,
+		points:    points,
+		rewards:   rewards,
+		trivia:    service.NewTriviaManager(triviaDB, rewards, config.Trivia, clock),
+		scramble:  service.NewScrambleManager(scrambleDB, rewards, config.Scramble, clock),
+		games:     service.NewCoordinator(config.Games, clock),
+		daily:     config.Daily,
+		roulette:  config.Roulette,
+		clock:     clock,
+		cooldowns: utils.NewCooldowns(clock),
+	}
+	ch.games.Register(triviaGame, ch.trivia)
+	ch.games.Register(scrambleGame, ch.scramble)
+
+	if len(config.Commands) > 0 {
+		ch.enabled = make(map[string]bool)
+		for _, command := range config.Commands {
+			cmd, ok := router.Lookup(command)
+			if !ok {
+				points.Close()
+				return nil, fmt.Errorf("unknown command %q enabled for %s", command, name)
+			}
+			ch.enabled[strings.ToLower(cmd.Name)] = true
+		}
+	}
+
+	channels.channels[name] = ch
+	log.Printf("Channel %s ready (prefix %q)", name, ch.Prefix)
+	return ch, nil
+}
+
+func RemoveChannel(name string) error {
+	name = NormalizeChannel(name)
+
+	channels.mutex.Lock()
+	ch, exists := channels.channels[name]
+	delete(channels.channels, name)
+	channels.mutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("channel %s not joined", name)
+	}
+
+	ch.games.Stop()
+	return closeChannel(ch)
+}
+
+func GetChannel(name string) *Channel {
+	channels.mutex.RLock()
+	defer channels.mutex.RUnlock()
+	return channels.channels[NormalizeChannel(name)]
+}
+
+func ChannelNames() []string {
+	channels.mutex.RLock()
+	defer channels.mutex.RUnlock()
+
+	names := make([]string, 0, len(channels.channels))
+	for name := range channels.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func SavePointsData() error {
+	var firstErr error
+	for _, ch := range snapshotChannels() {
+		if err := ch.points.SaveToFile(); err != nil {
+			log.Printf("Error saving points data for %s: %v", ch.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func ClosePointsData() error {
+	var firstErr error
+	for _, ch := range snapshotChannels() {
+		if err := closeChannel(ch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func NormalizeChannel(name string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+}
+
+func snapshotChannels() []*Channel {
+	channels.mutex.RLock()
+	defer channels.mutex.RUnlock()
+
+	list := make([]*Channel, 0, len(channels.channels))
+	for _, ch := range channels.channels {
+		list = append(list, ch)
+	}
+	return list
+}
+
+func closeChannel(ch *Channel) error {
+	if err := ch.points.SaveToFile(); err != nil {
+		log.Printf("Error saving points data for %s: %v", ch.Name, err)
+	}
+	return ch.points.Close()
+}
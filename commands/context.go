@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"twitchgo/types"
+)
+
+// ChatContext is everything a command needs to answer one chat message.
+type ChatContext struct {
+	Sender  types.Sender
+	Channel *Channel
+	Message types.ChatMessage
+}
+
+func NewChatContext(sender types.Sender, ch *Channel, message types.ChatMessage) *ChatContext {
+	return &ChatContext{
+		Sender:  sender,
+		Channel: ch,
+		Message: message,
+	}
+}
+
+func (c *ChatContext) User() types.ChatUser {
+	return c.Message.User
+}
+
+// Say sends text to the channel the message came from.
+func (c *ChatContext) Say(text string) {
+	c.Sender.Say(c.Message.Channel, text)
+}
+
+// Reply sends text as a threaded reply to the message.
+func (c *ChatContext) Reply(text string) {
+	c.Sender.Reply(c.Message.Channel, c.Message.ID, text)
+}
+
+// Whisper sends text privately to the author of the message.
+func (c *ChatContext) Whisper(text string) {
+	c.Sender.Whisper(c.Message.User.Name, text)
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"twitchgo/internal/chattest"
+	"twitchgo/internal/clocktest"
+	"twitchgo/internal/testenv"
+)
+
+// testChannel is a channel joined for one test, with its chat recorded and
+// its clock under the test's control.
+type testChannel struct {
+	*Channel
+	env   *testenv.Env
+	chat  *chattest.Recorder
+	clock *clocktest.Manual
+}
+
+// newTestChannel runs Setup over a testenv data directory and joins a
+// channel named after the test. configure, when given, edits the defaults
+// first, so channels joined with #entrar get the same config.
+func newTestChannel(t *testing.T, configure func(*ChannelConfig)) *testChannel {
+	t.Helper()
+
+	env := testenv.New(t)
+
+	defaults := DefaultChannelConfig()
+	defaults.Points.JSONPath = env.Path("user_data.json")
+	defaults.Points.SQLitePath = env.Path("points.db")
+	defaults.Points.LedgerPath = env.Path("ledger.jsonl")
+	defaults.Daily.Location = time.UTC
+	defaults.Clock = env.Clock
+	if configure != nil {
+		configure(&defaults)
+	}
+	Setup(defaults)
+
+	name := strings.ToLower(strings.NewReplacer("/", "", "_", "").Replace(t.Name()))
+	ch, err := AddChannel(defaults.ForChannel(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := RemoveChannel(ch.Name); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return &testChannel{Channel: ch, env: env, chat: env.Chat, clock: env.Clock}
+}
+
+// send hands text from user to the command router, or to the running games
+// when it is not a command, and returns what the bot said in reply.
+func (c *testChannel) send(user, text string, badges ...string) []string {
+	before := len(c.chat.Texts(c.Name))
+	ctx := NewChatContext(c.chat, c.Channel, c.chat.Message(c.Name, user, text, badges...))
+	if strings.HasPrefix(text, c.Prefix) {
+		Handle(ctx)
+	} else {
+		CheckGameAnswers(ctx)
+	}
+	return c.chat.Texts(c.Name)[before:]
+}
+
+// advance moves the clock and returns what the bot said meanwhile.
+func (c *testChannel) advance(d time.Duration) []string {
+	before := len(c.chat.Texts(c.Name))
+	c.clock.Advance(d)
+	return c.chat.Texts(c.Name)[before:]
+}
+
+// expect sends text and fails unless the single reply contains want.
+func (c *testChannel) expect(t *testing.T, user, text, want string, badges ...string) {
+	t.Helper()
+	replies := c.send(user, text, badges...)
+	if len(replies) != 1 || !strings.Contains(replies[0], want) {
+		t.Fatalf("%s: %q, replies = %q, want one containing %q", user, text, replies, want)
+	}
+}
+
+// expectAll sends text and fails unless the replies contain each of want,
+// in order.
+func (c *testChannel) expectAll(t *testing.T, user, text string, want ...string) {
+	t.Helper()
+	replies := c.send(user, text)
+	next := 0
+	for _, reply := range replies {
+		if next < len(want) && strings.Contains(reply, want[next]) {
+			next++
+		}
+	}
+	if next < len(want) {
+		t.Fatalf("%s: %q, replies = %q, want ones containing %q", user, text, replies, want)
+	}
+}
+
+// expectSilence sends text and fails if the bot answers.
+func (c *testChannel) expectSilence(t *testing.T, user, text string, badges ...string) {
+	t.Helper()
+	if replies := c.send(user, text, badges...); len(replies) != 0 {
+		t.Fatalf("%s: %q, replies = %q, want none", user, text, replies)
+	}
+}
+
+// withPermissions swaps the permission config for the test.
+func withPermissions(t *testing.T, config PermissionConfig) {
+	t.Helper()
+	previous := permissions
+	ConfigurePermissions(config)
+	t.Cleanup(func() { permissions = previous })
+}
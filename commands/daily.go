@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"twitchgo/types"
+)
+
+func DefaultDailyConfig() types.DailyConfig {
+	return types.DailyConfig{
+		Amount:         50,
+		StreakBonus:    10,
+		MaxStreakBonus: 100,
+		Period:         24 * time.Hour,
+		ResetHour:      0,
+		Location:       time.Local,
+	}
+}
+
+func DailyPoints(ctx *ChatContext, args []string) error {
+	username := ctx.User().Name
+	now := ctx.Channel.clock.Now()
+
+	claim, err := ctx.Channel.points.ClaimDaily(username, now, ctx.Channel.daily)
+	if err != nil {
+		return fmt.Errorf("claiming daily points for %s: %w", username, err)
+	}
+
+	if !claim.Claimed {
+		ctx.Say(fmt.Sprintf("[Diário] @%s Você já resgatou seus pontos. Próximo resgate em %s.",
+			ctx.User().DisplayName, formatDuration(claim.NextClaim.Sub(now))))
+		return nil
+	}
+
+	if claim.Bonus > 0 {
+		ctx.Say(fmt.Sprintf("[Diário] @%s Você recebeu %d pontos diários + %d de bônus (sequência de %d dias)! Novo saldo: %d",
+			ctx.User().DisplayName, claim.Amount, claim.Bonus, claim.Streak, claim.Balance))
+		return nil
+	}
+
+	ctx.Say(fmt.Sprintf("[Diário] @%s Você recebeu %d pontos diários! Novo saldo: %d",
+		ctx.User().DisplayName, claim.Amount, claim.Balance))
+	return nil
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "menos de 1min"
+	}
+
+	d = d.Round(time.Minute)
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+
+	if hours == 0 {
+		return fmt.Sprintf("%dmin", minutes)
+	}
+	return fmt.Sprintf("%dh%02dmin", hours, minutes)
+}
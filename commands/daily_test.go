@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDailyCooldown(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	// The clock starts at noon, so the next claim opens at midnight.
+	ch.expect(t, "viewer", "#diario", "Você recebeu 50 pontos diários! Novo saldo: 50")
+	ch.expect(t, "viewer", "#diario", "Próximo resgate em 12h")
+
+	ch.clock.Advance(11*time.Hour + 59*time.Minute + 30*time.Second)
+	ch.expect(t, "viewer", "#diario", "Próximo resgate em menos de 1min")
+
+	ch.clock.Advance(30 * time.Second)
+	ch.expect(t, "viewer", "#diario", "+ 10 de bônus (sequência de 2 dias)! Novo saldo: 110")
+
+	// Other viewers claim on their own.
+	ch.expect(t, "amigo", "#diario", "Você recebeu 50 pontos diários! Novo saldo: 50")
+}
+
+func TestDailyStreakResets(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#diario", "Novo saldo: 50")
+	ch.clock.Advance(24 * time.Hour)
+	ch.expect(t, "viewer", "#diario", "sequência de 2 dias")
+
+	// Missing a whole day starts the streak over.
+	ch.clock.Advance(48 * time.Hour)
+	ch.expect(t, "viewer", "#diario", "Você recebeu 50 pontos diários! Novo saldo: 160")
+}
+
+func TestDailyResetHour(t *testing.T) {
+	ch := newTestChannel(t, func(c *ChannelConfig) {
+		c.Daily.ResetHour = 15
+	})
+
+	ch.expect(t, "viewer", "#diario", "Novo saldo: 50")
+	ch.expect(t, "viewer", "#diario", "Próximo resgate em 3h")
+
+	ch.clock.Advance(3 * time.Hour)
+	ch.expect(t, "viewer", "#diario", "sequência de 2 dias")
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"time"
+
+	"twitchgo/service"
+)
+
+// Names the games are registered under with the coordinator, as chat sees
+// them.
+const (
+	triviaGame   = "quiz"
+	scrambleGame = "embaralha"
+)
+
+func DefaultGamesConfig() service.CoordinatorConfig {
+	return service.CoordinatorConfig{
+		MaxActive:  1,
+		QueueSize:  3,
+		QueueDelay: 5 * time.Second,
+	}
+}
+
+func GamesStatus(ctx *ChatContext, args []string) error {
+	ctx.Say(ctx.Channel.games.Status())
+	return nil
+}
+
+func ClearGamesQueue(ctx *ChatContext, args []string) error {
+	ctx.Say(ctx.Channel.games.ClearQueue())
+	return nil
+}
+
+// CheckGameAnswers hands a chat message to the games running in the channel.
+func CheckGameAnswers(ctx *ChatContext) {
+	ctx.Channel.games.CheckAnswer(ctx.Sender, ctx.Message)
+}
@@ -1,9 +1,6 @@
 package commands
 
-import (
-	"github.com/gempir/go-twitch-irc/v4"
-)
-
-func Hello(client *twitch.Client, message twitch.PrivateMessage) {
-	client.Say(message.Channel, "🤖 Olá! Eu sou um bot feito em Golang.")
+func Hello(ctx *ChatContext, args []string) error {
+	ctx.Say("🤖 Olá! Eu sou um bot feito em Golang.")
+	return nil
 }
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twitchgo/types"
+)
+
+var reasonLabels = map[types.Reason]string{
+	types.ReasonGamble:     "roleta",
+	types.ReasonTransfer:   "doação",
+	types.ReasonDaily:      "diário",
+	types.ReasonTrivia:     "quiz",
+	types.ReasonScramble:   "embaralha",
+	types.ReasonTournament: "torneio",
+	types.ReasonAdmin:      "admin",
+	types.ReasonOpening:    "saldo inicial",
+}
+
+func Statement(ctx *ChatContext, args []string) error {
+	history := ctx.Channel.points.GetHistory(ctx.User().Name, 5)
+	if len(history) == 0 {
+		ctx.Say(fmt.Sprintf("[Extrato] @%s Nenhuma movimentação encontrada.", ctx.User().DisplayName))
+		return nil
+	}
+
+	var statement strings.Builder
+	statement.WriteString(fmt.Sprintf("[Extrato] @%s ", ctx.User().DisplayName))
+
+	for i, entry := range history {
+		if i > 0 {
+			statement.WriteString(", ")
+		}
+		statement.WriteString(fmt.Sprintf("%+d (%s)", entry.Delta, describeEntry(entry)))
+	}
+	statement.WriteString(fmt.Sprintf(" | Saldo: %d", history[0].Balance))
+
+	ctx.Say(statement.String())
+	return nil
+}
+
+func Audit(ctx *ChatContext, args []string) error {
+	target := strings.ToLower(strings.TrimPrefix(args[0], "@"))
+	if !isAlphanumeric(target) {
+		return ErrUsage
+	}
+
+	audit, err := ctx.Channel.points.AuditUser(target)
+	if err != nil {
+		ctx.Say(fmt.Sprintf("[Auditoria] @%s Erro ao auditar %s.", ctx.User().DisplayName, target))
+		return err
+	}
+
+	status := "✅ consistente"
+	if !audit.Consistent() {
+		status = fmt.Sprintf("⚠️ divergência de %+d", audit.Balance-audit.Replayed)
+	}
+
+	ctx.Say(fmt.Sprintf("[Auditoria] %s: %d lançamentos, saldo pelo extrato %d, saldo atual %d %s",
+		audit.Username, audit.Entries, audit.Replayed, audit.Balance, status))
+	return nil
+}
+
+func describeEntry(entry types.LedgerEntry) string {
+	label, ok := reasonLabels[entry.Reason]
+	if !ok {
+		label = string(entry.Reason)
+	}
+
+	if entry.Counterparty == "" {
+		return label
+	}
+	if entry.Delta < 0 {
+		return fmt.Sprintf("%s para %s", label, entry.Counterparty)
+	}
+	return fmt.Sprintf("%s de %s", label, entry.Counterparty)
+}
@@ -0,0 +1,34 @@
+package commands
+
+import (
+	"testing"
+
+	"twitchgo/types"
+)
+
+func TestStatement(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#extrato", "[Extrato] @viewer Nenhuma movimentação encontrada.")
+
+	if err := ch.points.AddPoints("viewer", 100, types.ReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+	ch.expect(t, "viewer", "#doar amigo 30", "Doou 30 pontos")
+	ch.expect(t, "viewer", "#diario", "Novo saldo: 120")
+
+	ch.expect(t, "viewer", "#extrato", "[Extrato] @viewer +50 (diário), -30 (doação para amigo), +100 (admin) | Saldo: 120")
+	ch.expect(t, "amigo", "#extrato", "[Extrato] @amigo +30 (doação de viewer) | Saldo: 30")
+}
+
+func TestAudit(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "dono", "#addpontos viewer 100", "Adicionou 100 pontos", "broadcaster")
+	ch.expect(t, "viewer", "#doar amigo 40", "Doou 40 pontos")
+
+	ch.expect(t, "dono", "#auditar @Viewer", "[Auditoria] viewer: 2 lançamentos, saldo pelo extrato 60, saldo atual 60 ✅ consistente", "broadcaster")
+	ch.expect(t, "dono", "#auditar ninguem", "ninguem: 0 lançamentos, saldo pelo extrato 0, saldo atual 0", "broadcaster")
+	ch.expect(t, "dono", "#auditar a-b", "Uso: #auditar <usuario>", "broadcaster")
+	ch.expectSilence(t, "mod", "#auditar viewer", "moderator")
+}
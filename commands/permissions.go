@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"twitchgo/types"
+)
+
+type Role int
+
+const (
+	RoleEveryone Role = iota
+	RoleSubscriber
+	RoleVIP
+	RoleModerator
+	RoleBroadcaster
+	RoleAdmin
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleSubscriber:
+		return "subscriber"
+	case RoleVIP:
+		return "vip"
+	case RoleModerator:
+		return "moderator"
+	case RoleBroadcaster:
+		return "broadcaster"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "everyone"
+	}
+}
+
+type PermissionConfig struct {
+	Admins        []string `yaml:"admins"`
+	DeniedMessage string   `yaml:"denied_message"` // empty drops denied calls silently
+}
+
+var permissions = PermissionConfig{}
+
+func ConfigurePermissions(config PermissionConfig) {
+	admins := make([]string, 0, len(config.Admins))
+	for _, admin := range config.Admins {
+		admin = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(admin, "@")))
+		if admin != "" {
+			admins = append(admins, admin)
+		}
+	}
+	config.Admins = admins
+	permissions = config
+}
+
+func ResolveRole(user types.ChatUser) Role {
+	name := strings.ToLower(user.Name)
+	for _, admin := range permissions.Admins {
+		if admin == name {
+			return RoleAdmin
+		}
+	}
+
+	switch {
+	case user.Badges["broadcaster"] > 0:
+		return RoleBroadcaster
+	case user.Badges["moderator"] > 0:
+		return RoleModerator
+	case user.Badges["vip"] > 0:
+		return RoleVIP
+	case user.Badges["subscriber"] > 0, user.Badges["founder"] > 0:
+		return RoleSubscriber
+	default:
+		return RoleEveryone
+	}
+}
+
+func replyDenied(ctx *ChatContext) {
+	if permissions.DeniedMessage == "" {
+		return
+	}
+	ctx.Say(fmt.Sprintf("@%s %s", ctx.User().DisplayName, permissions.DeniedMessage))
+}
@@ -1,38 +1,168 @@
 package commands
 
-import (
-	"strings"
+var router = NewRouter()
 
-	"github.com/gempir/go-twitch-irc/v4"
-)
+func init() {
+	router.Register(&Command{Name: "hora", Handler: Time})
+	router.Register(&Command{Name: "bot", Handler: Hello})
 
-type CommandFunc func(client *twitch.Client, message twitch.PrivateMessage)
+	router.Register(&Command{
+		Name:    "quiz",
+		Usage:   "[categoria]",
+		Handler: Trivia,
+		Subcommands: []*Command{
+			{Name: "categorias", Aliases: []string{"categories"}, Handler: TriviaCategories},
+			{
+				Name:    "torneio",
+				Aliases: []string{"tournament"},
+				Usage:   "<perguntas> [categoria]",
+				MinArgs: 1,
+				Handler: TriviaTournament,
+				Subcommands: []*Command{
+					{Name: "pausar", Aliases: []string{"pause"}, MinRole: RoleModerator, Handler: PauseTriviaTournament},
+					{Name: "continuar", Aliases: []string{"resume"}, MinRole: RoleModerator, Handler: ResumeTriviaTournament},
+					{Name: "pular", Aliases: []string{"skip"}, MinRole: RoleModerator, Handler: SkipTriviaRound},
+					{Name: "encerrar", Aliases: []string{"end"}, MinRole: RoleModerator, Handler: EndTriviaTournament},
+				},
+			},
+			{Name: "parar", Aliases: []string{"stop"}, MinRole: RoleModerator, Handler: StopTrivia},
+			{
+				Name:    "adicionar",
+				Aliases: []string{"add"},
+				Usage:   "<pergunta> | <resposta> [| <alternativa>...]",
+				MinArgs: 1,
+				MinRole: RoleModerator,
+				Handler: AddTriviaQuestion,
+			},
+			{
+				Name:    "ativar",
+				Aliases: []string{"enable"},
+				Usage:   "<id>",
+				MinArgs: 1,
+				MaxArgs: 1,
+				MinRole: RoleModerator,
+				Handler: EnableTriviaQuestion,
+			},
+			{
+				Name:    "desativar",
+				Aliases: []string{"disable"},
+				Usage:   "<id>",
+				MinArgs: 1,
+				MaxArgs: 1,
+				MinRole: RoleModerator,
+				Handler: DisableTriviaQuestion,
+			},
+			{Name: "total", Aliases: []string{"count"}, MinRole: RoleModerator, Handler: TriviaCounts},
+			{Name: "recarregar", Aliases: []string{"reload"}, MinRole: RoleModerator, Handler: ReloadTrivia},
+		},
+	})
+	router.Register(&Command{Name: "paraquiz", MinRole: RoleModerator, Handler: StopTrivia})
 
-var commandMap = map[string]CommandFunc{
-	"hora":          Time,
-	"bot":           Hello,
-	"quiz":          Trivia,
-	"paraquiz":      StopTrivia,
-	"embaralha":     Scramble,
-	"paraembaralha": StopScramble,
-	"roleta":        Roulette,
-	"pontos":        Points,
-	"dar":           GivePoints,
-	"doar":          GivePoints,
-	"enviar":        GivePoints,
-	"top":           TopPoints,
-	"toppontos":     TopPoints,
-	"topperda":      TopGambleLoss,
-	"rank":          Rank,
-	"ranking":       Rank,
-	"addpontos":     AddPointsCommand,
-	"diario":        DailyPoints,
-}
+	router.Register(&Command{
+		Name:    "embaralha",
+		Handler: Scramble,
+		Subcommands: []*Command{
+			{Name: "parar", Aliases: []string{"stop"}, MinRole: RoleModerator, Handler: StopScramble},
+			{
+				Name:    "adicionar",
+				Aliases: []string{"add"},
+				Usage:   "<palavra> [| <categoria> | <dificuldade> | <definição>]",
+				MinArgs: 1,
+				MinRole: RoleModerator,
+				Handler: AddScrambleWord,
+			},
+			{
+				Name:    "ativar",
+				Aliases: []string{"enable"},
+				Usage:   "<id|palavra>",
+				MinArgs: 1,
+				MinRole: RoleModerator,
+				Handler: EnableScrambleWord,
+			},
+			{
+				Name:    "desativar",
+				Aliases: []string{"disable"},
+				Usage:   "<id|palavra>",
+				MinArgs: 1,
+				MinRole: RoleModerator,
+				Handler: DisableScrambleWord,
+			},
+			{
+				Name:    "lista",
+				Aliases: []string{"list"},
+				Usage:   "[categoria]",
+				MinRole: RoleModerator,
+				Handler: ListScrambleWords,
+			},
+			{Name: "total", Aliases: []string{"count"}, MinRole: RoleModerator, Handler: ScrambleCounts},
+			{Name: "recarregar", Aliases: []string{"reload"}, MinRole: RoleModerator, Handler: ReloadScramble},
+		},
+	})
+	router.Register(&Command{Name: "paraembaralha", MinRole: RoleModerator, Handler: StopScramble})
+	router.Register(&Command{
+		Name:    "jogos",
+		Aliases: []string{"games"},
+		Handler: GamesStatus,
+		Subcommands: []*Command{
+			{Name: "limpar", Aliases: []string{"clear"}, MinRole: RoleModerator, Handler: ClearGamesQueue},
+		},
+	})
 
-func Handle(client *twitch.Client, message twitch.PrivateMessage, prefix string) {
-	cmd := strings.ToLower(strings.TrimPrefix(message.Message, prefix))
+	router.Register(&Command{
+		Name:    "roleta",
+		Usage:   "<quantia|porcentagem%|all>",
+		MinArgs: 1,
+		MaxArgs: 1,
+		Handler: Roulette,
+	})
+	router.Register(&Command{Name: "pontos", Handler: Points})
+	router.Register(&Command{
+		Name:    "doar",
+		Aliases: []string{"dar", "enviar"},
+		Usage:   "<usuario> <quantia>",
+		MinArgs: 2,
+		MaxArgs: 2,
+		Handler: GivePoints,
+	})
+	router.Register(&Command{Name: "top", Aliases: []string{"toppontos"}, Handler: TopPoints})
+	router.Register(&Command{Name: "topperda", Handler: TopGambleLoss})
+	router.Register(&Command{Name: "rank", Aliases: []string{"ranking"}, Handler: Rank})
+	router.Register(&Command{
+		Name:    "addpontos",
+		Usage:   "<usuario> <quantia>",
+		MinArgs: 2,
+		MaxArgs: 2,
+		MinRole: RoleBroadcaster,
+		Handler: AddPointsCommand,
+	})
+	router.Register(&Command{Name: "diario", Handler: DailyPoints})
+	router.Register(&Command{Name: "extrato", Handler: Statement})
+	router.Register(&Command{
+		Name:    "entrar",
+		Usage:   "<canal>",
+		MinArgs: 1,
+		MaxArgs: 1,
+		MinRole: RoleAdmin,
+		Handler: JoinChannel,
+	})
+	router.Register(&Command{
+		Name:    "sair",
+		Usage:   "[canal]",
+		MaxArgs: 1,
+		MinRole: RoleAdmin,
+		Handler: PartChannel,
+	})
+	router.Register(&Command{Name: "canais", MinRole: RoleAdmin, Handler: ListChannels})
+	router.Register(&Command{
+		Name:    "auditar",
+		Usage:   "<usuario>",
+		MinArgs: 1,
+		MaxArgs: 1,
+		MinRole: RoleBroadcaster,
+		Handler: Audit,
+	})
+}
 
-	if handler, ok := commandMap[cmd]; ok {
-		handler(client, message)
-	}
+func Handle(ctx *ChatContext) {
+	router.Handle(ctx)
 }
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
@@ -8,30 +9,29 @@ import (
 	"time"
 
 	"twitchgo/types"
-	"twitchgo/utils"
-
-	"github.com/gempir/go-twitch-irc/v4"
 )
 
-var pointsDB types.PointsDatabase
-
-func init() {
-	pointsDB = utils.NewInMemoryPointsDB()
+type RouletteConfig struct {
+	// WinOdds is the chance of doubling the wager, from 0 to 1.
+	WinOdds float64 `yaml:"win_odds"`
+	// Cooldown is shared by the whole channel.
+	Cooldown time.Duration `yaml:"cooldown"`
 }
 
-func Roulette(client *twitch.Client, message twitch.PrivateMessage) {
-	if utils.IsOnCooldown("global", "roulette", 5*time.Second) {
-		log.Println("Roulette command blocked -- in silent cooldown.")
-		return
+func DefaultRouletteConfig() RouletteConfig {
+	return RouletteConfig{
+		WinOdds:  0.50,
+		Cooldown: 5 * time.Second,
 	}
+}
 
-	parts := strings.Fields(message.Message)
-	if len(parts) < 2 {
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Awkward Por favor especifique uma aposta.", message.User.DisplayName))
-		return
+func Roulette(ctx *ChatContext, args []string) error {
+	if ctx.Channel.cooldowns.IsOnCooldown(ctx.Channel.Name, "roulette", ctx.Channel.roulette.Cooldown) {
+		log.Println("Roulette command blocked -- in silent cooldown.")
+		return nil
 	}
 
-	wagerStr := strings.TrimSpace(parts[1])
+	wagerStr := strings.ToLower(strings.TrimSpace(args[0]))
 	var wager int
 	var format string
 	var err error
@@ -45,119 +45,112 @@ func Roulette(client *twitch.Client, message twitch.PrivateMessage) {
 		wager, err = strconv.Atoi(percentStr)
 		if err != nil {
 			log.Printf("Invalid wager format: %s", wagerStr)
-			return
+			return ErrUsage
 		}
 		if wager > 100 {
-			client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Weirdge Você não pode apostar mais de 100%% dos seus pontos.", message.User.DisplayName))
-			return
+			ctx.Say(fmt.Sprintf("[Roleta] @%s Weirdge Você não pode apostar mais de 100%% dos seus pontos.", ctx.User().DisplayName))
+			return nil
 		}
 	} else {
 		format = "points"
 		wager, err = strconv.Atoi(wagerStr)
 		if err != nil {
 			log.Printf("Invalid wager format: %s", wagerStr)
-			return
+			return ErrUsage
 		}
 	}
 
 	if format != "all" && wager < 0 {
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Madgay A aposta deve ser positiva.", message.User.DisplayName))
-		return
+		ctx.Say(fmt.Sprintf("[Roleta] @%s Madgay A aposta deve ser positiva.", ctx.User().DisplayName))
+		return nil
 	}
 
 	if format != "all" && wager == 0 {
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] 🫵 ICANT @%s acabou de tentar apostar 0 pontos", message.User.DisplayName))
-		return
+		ctx.Say(fmt.Sprintf("[Roleta] 🫵 ICANT @%s acabou de tentar apostar 0 pontos", ctx.User().DisplayName))
+		return nil
 	}
 
-	outcome, newBalance, delta, err := pointsDB.Gamble(message.User.Name, wager, format, 0.50)
+	outcome, newBalance, delta, err := ctx.Channel.points.Gamble(ctx.User().Name, wager, format, ctx.Channel.roulette.WinOdds)
 	if err != nil {
-		log.Printf("Error in gamble function: %v", err)
-		return
+		return fmt.Errorf("gamble: %w", err)
 	}
 
 	switch outcome {
 	case "win":
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Gayge Clap Você ganhou %d pontos e agora tem %d pontos.",
-			message.User.DisplayName, delta, newBalance))
+		ctx.Say(fmt.Sprintf("[Roleta] @%s Gayge Clap Você ganhou %d pontos e agora tem %d pontos.",
+			ctx.User().DisplayName, delta, newBalance))
 
 	case "lose":
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Sadgay SmokeTime Você perdeu %d pontos e agora tem %d pontos.",
-			message.User.DisplayName, delta, newBalance))
+		ctx.Say(fmt.Sprintf("[Roleta] @%s Sadgay SmokeTime Você perdeu %d pontos e agora tem %d pontos.",
+			ctx.User().DisplayName, delta, newBalance))
 
 	case "not enough points":
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Sadgay Você não tem pontos suficientes para isso. Aumente seu dinheiro.",
-			message.User.DisplayName))
+		ctx.Say(fmt.Sprintf("[Roleta] @%s Sadgay Você não tem pontos suficientes para isso. Aumente seu dinheiro.",
+			ctx.User().DisplayName))
 
 	case "no points":
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Madgay Você não tem nenhum ponto.",
-			message.User.DisplayName))
+		ctx.Say(fmt.Sprintf("[Roleta] @%s Madgay Você não tem nenhum ponto.",
+			ctx.User().DisplayName))
 
 	case "invalid percent":
-		client.Say(message.Channel, fmt.Sprintf("[Roleta] @%s Weirdge Percentual inválido.",
-			message.User.DisplayName))
+		ctx.Say(fmt.Sprintf("[Roleta] @%s Weirdge Percentual inválido.",
+			ctx.User().DisplayName))
 	}
+	return nil
 }
 
-func Points(client *twitch.Client, message twitch.PrivateMessage) {
-	username := message.User.Name
-	points := pointsDB.GetPoints(username)
+func Points(ctx *ChatContext, args []string) error {
+	username := ctx.User().Name
+	points := ctx.Channel.points.GetPoints(username)
 
-	client.Say(message.Channel, fmt.Sprintf("@%s Você tem %d pontos.", message.User.DisplayName, points))
+	ctx.Say(fmt.Sprintf("@%s Você tem %d pontos.", ctx.User().DisplayName, points))
+	return nil
 }
 
-func GivePoints(client *twitch.Client, message twitch.PrivateMessage) {
-	parts := strings.Fields(message.Message)
-	if len(parts) != 3 {
-		client.Say(message.Channel, fmt.Sprintf("[Doar] @%s Uso: #doar <usuario> <quantia>", message.User.DisplayName))
-		return
-	}
-
-	receiver := strings.TrimPrefix(parts[1], "@")
-	amountStr := parts[2]
+func GivePoints(ctx *ChatContext, args []string) error {
+	receiver := strings.TrimPrefix(args[0], "@")
+	amountStr := args[1]
 
 	if !isAlphanumeric(receiver) {
 		log.Printf("Invalid recipient: %s (not alphanumeric)", receiver)
-		return
+		return ErrUsage
 	}
 
 	amount, err := strconv.Atoi(amountStr)
 	if err != nil {
 		log.Printf("Invalid amount: %s", amountStr)
-		return
+		return ErrUsage
 	}
 
 	if amount <= 0 {
-		client.Say(message.Channel, fmt.Sprintf("[Doar] @%s A quantia deve ser positiva.", message.User.DisplayName))
-		return
-	}
-
-	senderPoints := pointsDB.GetPoints(message.User.Name)
-	if senderPoints < amount {
-		client.Say(message.Channel, fmt.Sprintf("[Doar] @%s Madgay Você não pode doar mais pontos do que tem.", message.User.DisplayName))
-		return
-	}
-
-	err = pointsDB.TransferPoints(message.User.Name, receiver, amount)
-	if err != nil {
-		if strings.Contains(err.Error(), "cannot transfer to yourself") {
-			client.Say(message.Channel, fmt.Sprintf("🫵 ICANT @%s Não funcionou.", message.User.DisplayName))
-		} else {
-			client.Say(message.Channel, fmt.Sprintf("[Doar] @%s Transferência falhou.", message.User.DisplayName))
-		}
-		return
-	}
-
-	client.Say(message.Channel, fmt.Sprintf("[Doar] @%s Doou %d pontos para %s.",
-		message.User.DisplayName, amount, receiver))
+		ctx.Say(fmt.Sprintf("[Doar] @%s A quantia deve ser positiva.", ctx.User().DisplayName))
+		return nil
+	}
+
+	err = ctx.Channel.points.TransferPoints(ctx.User().Name, receiver, amount)
+	switch {
+	case errors.Is(err, types.ErrInsufficientPoints):
+		ctx.Say(fmt.Sprintf("[Doar] @%s Madgay Você não pode doar mais pontos do que tem.", ctx.User().DisplayName))
+		return nil
+	case errors.Is(err, types.ErrSelfTransfer):
+		ctx.Say(fmt.Sprintf("🫵 ICANT @%s Não funcionou.", ctx.User().DisplayName))
+		return nil
+	case err != nil:
+		ctx.Say(fmt.Sprintf("[Doar] @%s Transferência falhou.", ctx.User().DisplayName))
+		return err
+	}
+
+	ctx.Say(fmt.Sprintf("[Doar] @%s Doou %d pontos para %s.",
+		ctx.User().DisplayName, amount, receiver))
+	return nil
 }
 
-func TopPoints(client *twitch.Client, message twitch.PrivateMessage) {
-	usernames, points := pointsDB.GetTopPoints(5)
+func TopPoints(ctx *ChatContext, args []string) error {
+	usernames, points := ctx.Channel.points.GetTopPoints(5)
 
 	if len(usernames) == 0 {
-		client.Say(message.Channel, "[TopPontos] Nenhum usuário encontrado.")
-		return
+		ctx.Say("[TopPontos] Nenhum usuário encontrado.")
+		return nil
 	}
 
 	var leaderboard strings.Builder
@@ -170,15 +163,16 @@ func TopPoints(client *twitch.Client, message twitch.PrivateMessage) {
 		leaderboard.WriteString(fmt.Sprintf("%d. %s (%d)", i+1, username, points[i]))
 	}
 
-	client.Say(message.Channel, leaderboard.String())
+	ctx.Say(leaderboard.String())
+	return nil
 }
 
-func TopGambleLoss(client *twitch.Client, message twitch.PrivateMessage) {
-	usernames, losses := pointsDB.GetTopGambleLoss(5)
+func TopGambleLoss(ctx *ChatContext, args []string) error {
+	usernames, losses := ctx.Channel.points.GetTopGambleLoss(5)
 
 	if len(usernames) == 0 {
-		client.Say(message.Channel, "[TopPontos] Nenhum usuário encontrado.")
-		return
+		ctx.Say("[TopPontos] Nenhum usuário encontrado.")
+		return nil
 	}
 
 	var leaderboard strings.Builder
@@ -191,65 +185,43 @@ func TopGambleLoss(client *twitch.Client, message twitch.PrivateMessage) {
 		leaderboard.WriteString(fmt.Sprintf("%d. %s (%d)", i+1, username, losses[i]))
 	}
 
-	client.Say(message.Channel, leaderboard.String())
+	ctx.Say(leaderboard.String())
+	return nil
 }
 
-func Rank(client *twitch.Client, message twitch.PrivateMessage) {
-	pointsRank, lossRank := pointsDB.GetRank(message.User.Name)
+func Rank(ctx *ChatContext, args []string) error {
+	pointsRank, lossRank := ctx.Channel.points.GetRank(ctx.User().Name)
 
-	client.Say(message.Channel, fmt.Sprintf("@%s Sua posição em pontos é %d e sua posição em perdas de apostas é %d.",
-		message.User.DisplayName, pointsRank, lossRank))
+	ctx.Say(fmt.Sprintf("@%s Sua posição em pontos é %d e sua posição em perdas de apostas é %d.",
+		ctx.User().DisplayName, pointsRank, lossRank))
+	return nil
 }
 
-func AddPointsCommand(client *twitch.Client, message twitch.PrivateMessage) {
-	parts := strings.Fields(message.Message)
-	if len(parts) != 3 {
-		client.Say(message.Channel, fmt.Sprintf("[AddPontos] @%s Uso: #addpontos <usuario> <quantia>", message.User.DisplayName))
-		return
-	}
-
-	targetUser := strings.TrimPrefix(parts[1], "@")
-	amountStr := parts[2]
+func AddPointsCommand(ctx *ChatContext, args []string) error {
+	targetUser := strings.TrimPrefix(args[0], "@")
+	amountStr := args[1]
 
 	amount, err := strconv.Atoi(amountStr)
 	if err != nil {
-		client.Say(message.Channel, fmt.Sprintf("[AddPontos] @%s Quantia inválida.", message.User.DisplayName))
-		return
+		ctx.Say(fmt.Sprintf("[AddPontos] @%s Quantia inválida.", ctx.User().DisplayName))
+		return nil
 	}
 
 	if amount <= 0 {
-		client.Say(message.Channel, fmt.Sprintf("[AddPontos] @%s A quantia deve ser positiva.", message.User.DisplayName))
-		return
-	}
-
-	err = pointsDB.AddPoints(targetUser, amount)
-	if err != nil {
-		client.Say(message.Channel, fmt.Sprintf("[AddPontos] @%s Erro ao adicionar pontos.", message.User.DisplayName))
-		return
+		ctx.Say(fmt.Sprintf("[AddPontos] @%s A quantia deve ser positiva.", ctx.User().DisplayName))
+		return nil
 	}
 
-	newBalance := pointsDB.GetPoints(targetUser)
-	client.Say(message.Channel, fmt.Sprintf("[AddPontos] @%s Adicionou %d pontos a %s (novo saldo: %d).",
-		message.User.DisplayName, amount, targetUser, newBalance))
-}
-
-func SavePointsData() error {
-	return pointsDB.SaveToFile()
-}
-
-func DailyPoints(client *twitch.Client, message twitch.PrivateMessage) {
-	username := message.User.Name
-
-	dailyAmount := 50
-	err := pointsDB.AddPoints(username, dailyAmount)
+	err = ctx.Channel.points.AddPoints(targetUser, amount, types.ReasonAdmin)
 	if err != nil {
-		log.Printf("Error adding daily points to %s: %v", username, err)
-		return
+		ctx.Say(fmt.Sprintf("[AddPontos] @%s Erro ao adicionar pontos.", ctx.User().DisplayName))
+		return nil
 	}
 
-	newBalance := pointsDB.GetPoints(username)
-	client.Say(message.Channel, fmt.Sprintf("[Diário] @%s Você recebeu %d pontos diários! Novo saldo: %d",
-		message.User.DisplayName, dailyAmount, newBalance))
+	newBalance := ctx.Channel.points.GetPoints(targetUser)
+	ctx.Say(fmt.Sprintf("[AddPontos] @%s Adicionou %d pontos a %s (novo saldo: %d).",
+		ctx.User().DisplayName, amount, targetUser, newBalance))
+	return nil
 }
 
 func isAlphanumeric(s string) bool {
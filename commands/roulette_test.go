@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"twitchgo/types"
+)
+
+func TestGivePointsErrors(t *testing.T) {
+	ch := newTestChannel(t, nil)
+	if err := ch.points.AddPoints("viewer", 50, types.ReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"#doar amigo 0", "A quantia deve ser positiva"},
+		{"#doar amigo -5", "A quantia deve ser positiva"},
+		{"#doar amigo 51", "Você não pode doar mais pontos do que tem"},
+		{"#doar viewer 10", "Não funcionou"},
+		{"#doar @Viewer 10", "Não funcionou"},
+		{"#doar amigo dez", "Uso: #doar"},
+	}
+	for _, tt := range tests {
+		ch.expect(t, "viewer", tt.text, tt.want)
+	}
+
+	if points := ch.points.GetPoints("viewer"); points != 50 {
+		t.Fatalf("viewer has %d points after failed donations, want 50", points)
+	}
+
+	ch.expect(t, "viewer", "#doar @Amigo 20", "Doou 20 pontos para Amigo")
+	ch.expect(t, "amigo", "#pontos", "Você tem 20 pontos")
+	ch.expect(t, "viewer", "#pontos", "Você tem 30 pontos")
+}
+
+func TestRouletteConfig(t *testing.T) {
+	ch := newTestChannel(t, func(c *ChannelConfig) {
+		c.Roulette = RouletteConfig{WinOdds: 1, Cooldown: 30 * time.Second}
+	})
+	if err := ch.points.AddPoints("viewer", 100, types.ReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+
+	ch.expect(t, "viewer", "#roleta 40", "Você ganhou 40 pontos e agora tem 140 pontos")
+
+	ch.clock.Advance(29 * time.Second)
+	ch.expectSilence(t, "viewer", "#roleta 40")
+
+	ch.clock.Advance(time.Second)
+	ch.expect(t, "viewer", "#roleta 50%", "Você ganhou 70 pontos e agora tem 210 pontos")
+}
+
+func TestLeaderboards(t *testing.T) {
+	ch := newTestChannel(t, func(c *ChannelConfig) {
+		c.Roulette = RouletteConfig{WinOdds: 0}
+	})
+
+	ch.expect(t, "viewer", "#top", "[TopPontos] Nenhum usuário encontrado.")
+	ch.expect(t, "viewer", "#topperda", "[TopPontos] Nenhum usuário encontrado.")
+
+	for user, points := range map[string]int{"alice": 300, "bob": 200, "carol": 100} {
+		if err := ch.points.AddPoints(user, points, types.ReasonAdmin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ch.expect(t, "bob", "#roleta 150", "Você perdeu 150 pontos")
+	ch.expect(t, "carol", "#roleta 20", "Você perdeu 20 pontos")
+
+	ch.expect(t, "viewer", "#top", "Top Points: 1. alice (300), 2. carol (80), 3. bob (50)")
+	ch.expect(t, "viewer", "#toppontos", "1. alice (300)")
+	ch.expect(t, "viewer", "#topperda", "Top Perdas em Apostas: 1. bob (150), 2. carol (20)")
+}
+
+func TestRank(t *testing.T) {
+	ch := newTestChannel(t, func(c *ChannelConfig) {
+		c.Roulette = RouletteConfig{WinOdds: 0}
+	})
+
+	if err := ch.points.AddPoints("alice", 100, types.ReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if err := ch.points.AddPoints("bob", 50, types.ReasonAdmin); err != nil {
+		t.Fatal(err)
+	}
+	ch.expect(t, "alice", "#roleta 10", "Você perdeu 10 pontos")
+
+	ch.expect(t, "alice", "#rank", "@alice Sua posição em pontos é 1 e sua posição em perdas de apostas é 1.")
+	ch.expect(t, "bob", "#ranking", "Sua posição em pontos é 2 e sua posição em perdas de apostas é 2.")
+	// A viewer the bot has never seen ranks after everyone with points.
+	ch.expect(t, "Novato", "#rank", "@Novato Sua posição em pontos é 3 e sua posição em perdas de apostas é 2.")
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+type CommandFunc func(ctx *ChatContext, args []string) error
+
+// ErrUsage makes the router answer with the command usage string.
+var ErrUsage = errors.New("invalid command usage")
+
+type Command struct {
+	Name        string
+	Aliases     []string
+	Usage       string
+	MinArgs     int
+	MaxArgs     int // 0 means no upper limit
+	MinRole     Role
+	Handler     CommandFunc
+	Subcommands []*Command
+}
+
+type Router struct {
+	commands map[string]*Command
+}
+
+func NewRouter() *Router {
+	return &Router{
+		commands: make(map[string]*Command),
+	}
+}
+
+func (r *Router) Register(cmd *Command) {
+	for _, name := range cmd.names() {
+		if _, exists := r.commands[name]; exists {
+			panic(fmt.Sprintf("command %q registered twice", name))
+		}
+		r.commands[name] = cmd
+	}
+}
+
+func (r *Router) Lookup(name string) (*Command, bool) {
+	cmd, ok := r.commands[strings.ToLower(name)]
+	return cmd, ok
+}
+
+func (r *Router) Handle(ctx *ChatContext) {
+	ch := ctx.Channel
+	prefix := ch.Prefix
+	name, args := ParseCommand(ctx.Message.Text, prefix)
+	if name == "" {
+		return
+	}
+
+	cmd, ok := r.Lookup(name)
+	if !ok || !ch.CommandEnabled(cmd.Name) {
+		return
+	}
+
+	path := []string{name}
+	required := cmd.MinRole
+	for len(args) > 0 {
+		sub, ok := cmd.subcommand(args[0])
+		if !ok {
+			break
+		}
+		cmd = sub
+		path = append(path, strings.ToLower(args[0]))
+		args = args[1:]
+		if cmd.MinRole > required {
+			required = cmd.MinRole
+		}
+	}
+
+	if role := ResolveRole(ctx.User()); role < required {
+		log.Printf("Command %s denied for %s (role %s, requires %s)",
+			strings.Join(path, " "), ctx.User().Name, role, required)
+		replyDenied(ctx)
+		return
+	}
+
+	if cmd.Handler == nil || len(args) < cmd.MinArgs || (cmd.MaxArgs > 0 && len(args) > cmd.MaxArgs) {
+		replyUsage(ctx, prefix, path, cmd)
+		return
+	}
+
+	if err := cmd.Handler(ctx, args); err != nil {
+		if errors.Is(err, ErrUsage) {
+			replyUsage(ctx, prefix, path, cmd)
+			return
+		}
+		log.Printf("Command %s failed: %v", strings.Join(path, " "), err)
+	}
+}
+
+func ParseCommand(text, prefix string) (string, []string) {
+	fields := strings.Fields(strings.TrimPrefix(text, prefix))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(fields[0]), fields[1:]
+}
+
+func (c *Command) names() []string {
+	names := []string{strings.ToLower(c.Name)}
+	for _, alias := range c.Aliases {
+		names = append(names, strings.ToLower(alias))
+	}
+	return names
+}
+
+func (c *Command) subcommand(name string) (*Command, bool) {
+	name = strings.ToLower(name)
+	for _, sub := range c.Subcommands {
+		for _, n := range sub.names() {
+			if n == name {
+				return sub, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func replyUsage(ctx *ChatContext, prefix string, path []string, cmd *Command) {
+	usage := prefix + strings.Join(path, " ")
+	if cmd.Usage != "" {
+		usage += " " + cmd.Usage
+	}
+
+	if len(cmd.Subcommands) > 0 {
+		var subs []string
+		for _, sub := range cmd.Subcommands {
+			subs = append(subs, sub.Name)
+		}
+		usage += fmt.Sprintf(" (%s)", strings.Join(subs, ", "))
+	}
+
+	ctx.Say(fmt.Sprintf("@%s Uso: %s", ctx.User().DisplayName, usage))
+}
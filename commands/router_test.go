@@ -0,0 +1,132 @@
+package commands
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text, prefix string
+		name         string
+		args         int
+	}{
+		{"#roleta 50", "#", "roleta", 1},
+		{"#ROLETA   all ", "#", "roleta", 1},
+		{"!doar amigo 10", "!", "doar", 2},
+		{"#", "#", "", 0},
+		{"#   ", "#", "", 0},
+	}
+
+	for _, tt := range tests {
+		name, args := ParseCommand(tt.text, tt.prefix)
+		if name != tt.name || len(args) != tt.args {
+			t.Errorf("ParseCommand(%q, %q) = %q, %q; want %q with %d args",
+				tt.text, tt.prefix, name, args, tt.name, tt.args)
+		}
+	}
+}
+
+func TestRouterUsage(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		// Too few arguments.
+		{"#roleta", "Uso: #roleta <quantia|porcentagem%|all>"},
+		{"#doar amigo", "Uso: #doar <usuario> <quantia>"},
+		// Too many.
+		{"#roleta 10 20", "Uso: #roleta <quantia|porcentagem%|all>"},
+		// Handlers returning ErrUsage.
+		{"#roleta muito", "Uso: #roleta"},
+		{"#doar amigo dez", "Uso: #doar <usuario> <quantia>"},
+		{"#doar @!! 10", "Uso: #doar"},
+		// Aliases report the name that was typed.
+		{"#dar amigo", "Uso: #dar <usuario> <quantia>"},
+		// Subcommand usage.
+		{"#quiz torneio", "Uso: #quiz torneio <perguntas> [categoria] (pausar, continuar, pular, encerrar)"},
+		{"#quiz torneio zero", "Uso: #quiz torneio"},
+	}
+
+	for _, tt := range tests {
+		ch.expect(t, "viewer", tt.text, tt.want)
+	}
+}
+
+func TestRouterIgnoresOtherMessages(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	ch.expectSilence(t, "viewer", "#naoexiste")
+	ch.expectSilence(t, "viewer", "bom dia")
+	ch.expectSilence(t, "viewer", "!pontos")
+	ch.expect(t, "viewer", "#PONTOS", "Você tem 0 pontos")
+}
+
+func TestRouterPrefixAndEnabledCommands(t *testing.T) {
+	ch := newTestChannel(t, func(c *ChannelConfig) {
+		c.Prefix = "!"
+		c.Commands = []string{"pontos", "dar"}
+	})
+
+	ch.expect(t, "viewer", "!pontos", "Você tem 0 pontos")
+	// Enabling an alias enables the command.
+	ch.expect(t, "viewer", "!doar amigo", "Uso: !doar")
+	ch.expectSilence(t, "viewer", "!roleta 10")
+	ch.expectSilence(t, "viewer", "#pontos")
+}
+
+func TestRouterDeniedRoles(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	t.Run("silent", func(t *testing.T) {
+		withPermissions(t, PermissionConfig{})
+
+		ch.expectSilence(t, "viewer", "#addpontos viewer 100")
+		ch.expectSilence(t, "mod", "#addpontos viewer 100", "moderator")
+		ch.expectSilence(t, "viewer", "#quiz parar")
+		ch.expect(t, "viewer", "#pontos", "Você tem 0 pontos")
+	})
+
+	t.Run("message", func(t *testing.T) {
+		withPermissions(t, PermissionConfig{DeniedMessage: "Sem permissão."})
+
+		ch.expect(t, "viewer", "#addpontos viewer 100", "@viewer Sem permissão.")
+		ch.expect(t, "sub", "#embaralha parar", "@sub Sem permissão.", "subscriber")
+		// The role check comes before the argument check.
+		ch.expect(t, "viewer", "#addpontos", "Sem permissão.")
+		ch.expect(t, "dono", "#entrar outrocanal", "Sem permissão.", "broadcaster")
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		withPermissions(t, PermissionConfig{Admins: []string{"@Chefe"}})
+
+		ch.expect(t, "dono", "#addpontos viewer 100", "Adicionou 100 pontos a viewer", "broadcaster")
+		ch.expect(t, "chefe", "#addpontos viewer 5", "novo saldo: 105")
+		ch.expect(t, "chefe", "#canais", "[Canais]")
+	})
+}
+
+func TestResolveRole(t *testing.T) {
+	withPermissions(t, PermissionConfig{Admins: []string{" Chefe "}})
+	ch := newTestChannel(t, nil)
+
+	tests := []struct {
+		user   string
+		badges []string
+		want   Role
+	}{
+		{"viewer", nil, RoleEveryone},
+		{"sub", []string{"subscriber"}, RoleSubscriber},
+		{"founder", []string{"founder"}, RoleSubscriber},
+		{"vip", []string{"vip", "subscriber"}, RoleVIP},
+		{"mod", []string{"moderator", "vip"}, RoleModerator},
+		{"dono", []string{"broadcaster", "moderator"}, RoleBroadcaster},
+		{"chefe", nil, RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		user := ch.chat.Message(ch.Name, tt.user, "", tt.badges...).User
+		if got := ResolveRole(user); got != tt.want {
+			t.Errorf("ResolveRole(%s %v) = %s, want %s", tt.user, tt.badges, got, tt.want)
+		}
+	}
+}
@@ -1,37 +1,196 @@
 package commands
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"twitchgo/service"
+	"twitchgo/types"
 	"twitchgo/utils"
-
-	"github.com/gempir/go-twitch-irc/v4"
 )
 
-var scrambleManager *service.ScrambleManager
+// scrambleListLength keeps "#embaralha lista" within one chat message.
+const scrambleListLength = 400
+
+func DefaultScrambleConfig() service.ScrambleConfig {
+	return service.ScrambleConfig{
+		RoundConfig: service.RoundConfig{
+			Cooldown:  10 * time.Second,
+			Timeout:   40 * time.Second,
+			MaxLength: 250,
+			Hints: service.HintConfig{
+				Stages: []service.HintStage{
+					{At: 0.33, Reveal: 0.2},
+					{At: 0.5, Reveal: 0.35},
+					{At: 0.66, Reveal: 0.5},
+				},
+				Penalty: 0.2,
+			},
+			Match: utils.DefaultScrambleMatchConfig(),
+			Reward: service.RewardConfig{
+				BasePoints:      6,
+				BonusPoints:     8,
+				BonusSimilarity: 0.95,
+			},
+		},
+		Difficulty: map[types.Difficulty]service.DifficultyScale{
+			types.DifficultyEasy:   {Reward: 0.5, Time: 0.8},
+			types.DifficultyMedium: {Reward: 1, Time: 1},
+			types.DifficultyHard:   {Reward: 1.5, Time: 1.25},
+		},
+		Shuffle: map[types.Difficulty]utils.ScrambleOptions{
+			types.DifficultyEasy:   {Shuffle: 0.5, KeepFirst: true},
+			types.DifficultyMedium: {Shuffle: 1, KeepFirst: true},
+			types.DifficultyHard:   {Shuffle: 1},
+		},
+		Length: service.LengthBonus{From: 6, PerLetter: 0.1},
+	}
+}
+
+func Scramble(ctx *ChatContext, args []string) error {
+	ctx.Channel.games.Request(ctx.Sender, ctx.Message, scrambleGame, func() {
+		ctx.Channel.scramble.StartScramble(ctx.Sender, ctx.Message)
+	})
+	return nil
+}
+
+func StopScramble(ctx *ChatContext, args []string) error {
+	ctx.Channel.scramble.StopScramble(ctx.Sender, ctx.Message)
+	return nil
+}
+
+// AddScrambleWord adds "<palavra> [| categoria] [| dificuldade] [| definição]"
+// to the pool and saves it.
+func AddScrambleWord(ctx *ChatContext, args []string) error {
+	parts := strings.Split(strings.Join(args, " "), "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) > 4 || parts[0] == "" {
+		return ErrUsage
+	}
+
+	word := types.ScrambleWord{Word: parts[0], Enabled: true}
+	if len(parts) > 1 {
+		word.Category = parts[1]
+	}
+	if len(parts) > 2 {
+		difficulty, ok := types.ParseDifficulty(parts[2])
+		if !ok {
+			return ErrUsage
+		}
+		word.Difficulty = difficulty
+	}
+	if len(parts) > 3 {
+		word.Definition = parts[3]
+	}
+
+	if !utils.CanScramble(word.Word) {
+		ctx.Say(fmt.Sprintf("[Embaralha] @%s A palavra %s não pode ser embaralhada.",
+			ctx.User().DisplayName, word.Word))
+		return nil
+	}
 
-func init() {
-	database := utils.NewInMemoryScrambleDB()
+	if existing := scrambleDB.GetWord(word.Word); existing != nil {
+		ctx.Say(fmt.Sprintf("[Embaralha] @%s A palavra %s já existe (%s).",
+			ctx.User().DisplayName, existing.Word, existing.ID))
+		return nil
+	}
 
-	config := service.ScrambleConfig{
-		Cooldown:  10 * time.Second,
-		HintTime:  20 * time.Second,
-		Timeout:   40 * time.Second,
-		MaxLength: 250,
+	id := scrambleDB.AddWord(word)
+	if err := saveScrambleWords(ctx); err != nil {
+		return err
 	}
 
-	scrambleManager = service.NewScrambleManager(database, config)
+	ctx.Say(fmt.Sprintf("[Embaralha] @%s Palavra %s adicionada.", ctx.User().DisplayName, id))
+	return nil
 }
 
-func Scramble(client *twitch.Client, message twitch.PrivateMessage) {
-	scrambleManager.StartScramble(client, message)
+// EnableScrambleWord and DisableScrambleWord take a word's ID or the word
+// itself.
+func EnableScrambleWord(ctx *ChatContext, args []string) error {
+	return setScrambleWordEnabled(ctx, strings.Join(args, " "), true)
 }
 
-func StopScramble(client *twitch.Client, message twitch.PrivateMessage) {
-	scrambleManager.StopScramble(client, message)
+func DisableScrambleWord(ctx *ChatContext, args []string) error {
+	return setScrambleWordEnabled(ctx, strings.Join(args, " "), false)
 }
 
-func CheckScrambleAnswer(client *twitch.Client, message twitch.PrivateMessage) {
-	scrambleManager.CheckAnswer(client, message, utils.CheckScrambleGuess)
+// ListScrambleWords shows "#embaralha lista [categoria]" as "id palavra"
+// pairs, cut short to fit one message.
+func ListScrambleWords(ctx *ChatContext, args []string) error {
+	category := strings.Join(args, " ")
+	words := scrambleDB.ListWords(category)
+	if len(words) == 0 {
+		ctx.Say("[Embaralha] Nenhuma palavra encontrada.")
+		return nil
+	}
+
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("[Embaralha] %d palavras:", len(words)))
+	for i, word := range words {
+		entry := fmt.Sprintf(" %s %s", word.ID, word.Word)
+		if !word.Enabled {
+			entry += " (desativada)"
+		}
+		if i > 0 {
+			entry = "," + entry
+		}
+
+		if list.Len()+len(entry) > scrambleListLength {
+			list.WriteString(fmt.Sprintf(" … (+%d)", len(words)-i))
+			break
+		}
+		list.WriteString(entry)
+	}
+
+	ctx.Say(list.String())
+	return nil
+}
+
+func ScrambleCounts(ctx *ChatContext, args []string) error {
+	ctx.Say(fmt.Sprintf("[Embaralha] %d palavras, %d ativas.",
+		scrambleDB.GetWordCount(), scrambleDB.GetEnabledWordCount()))
+	return nil
+}
+
+func ReloadScramble(ctx *ChatContext, args []string) error {
+	if err := scrambleDB.ReloadWords(); err != nil {
+		ctx.Say(fmt.Sprintf("[Embaralha] @%s Não consegui recarregar as palavras.", ctx.User().DisplayName))
+		return fmt.Errorf("reloading scramble words: %w", err)
+	}
+
+	ctx.Say(fmt.Sprintf("[Embaralha] @%s %d palavras carregadas, %d ativas.",
+		ctx.User().DisplayName, scrambleDB.GetWordCount(), scrambleDB.GetEnabledWordCount()))
+	return nil
+}
+
+func setScrambleWordEnabled(ctx *ChatContext, idOrWord string, enabled bool) error {
+	var found bool
+	var state string
+	if enabled {
+		found, state = scrambleDB.EnableWord(idOrWord), "ativada"
+	} else {
+		found, state = scrambleDB.DisableWord(idOrWord), "desativada"
+	}
+
+	if !found {
+		ctx.Say(fmt.Sprintf("[Embaralha] @%s Palavra %s não encontrada.", ctx.User().DisplayName, idOrWord))
+		return nil
+	}
+	if err := saveScrambleWords(ctx); err != nil {
+		return err
+	}
+
+	ctx.Say(fmt.Sprintf("[Embaralha] @%s Palavra %s %s.", ctx.User().DisplayName, idOrWord, state))
+	return nil
+}
+
+func saveScrambleWords(ctx *ChatContext) error {
+	if err := scrambleDB.SaveToJSONFile(utils.ScrambleWordsFile); err != nil {
+		ctx.Say(fmt.Sprintf("[Embaralha] @%s Não consegui salvar as palavras.", ctx.User().DisplayName))
+		return fmt.Errorf("saving scramble words: %w", err)
+	}
+	return nil
 }
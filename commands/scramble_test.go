@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"twitchgo/internal/testenv"
+	"twitchgo/types"
+)
+
+func TestScrambleStartAndStop(t *testing.T) {
+	withPermissions(t, PermissionConfig{})
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#embaralha", "[Embaralha]")
+	ch.expectSilence(t, "viewer", "#embaralha parar")
+	ch.expect(t, "mod", "#embaralha parar", "[Embaralha] MrDestructoid Scramble parou.", "moderator")
+
+	ch.clock.Advance(time.Minute)
+	ch.expect(t, "viewer", "#embaralha", "[Embaralha]")
+	ch.expect(t, "mod", "#paraembaralha", "Scramble parou.", "moderator")
+	ch.expectSilence(t, "viewer", "gaules")
+}
+
+func TestScrambleManagement(t *testing.T) {
+	withPermissions(t, PermissionConfig{Admins: []string{"chefe"}})
+	ch := newTestChannel(t, nil)
+
+	replies := ch.send("chefe", "#embaralha adicionar Casimiro | streamers | fácil | Dono da Cazé TV")
+	added := regexp.MustCompile(`Palavra (\S+) adicionada\.`).FindStringSubmatch(strings.Join(replies, "\n"))
+	if added == nil {
+		t.Fatalf("#embaralha adicionar replied %q", replies)
+	}
+	id := added[1]
+
+	ch.expect(t, "chefe", "#embaralha adicionar GAULES", "[Embaralha] @chefe A palavra gaules já existe (s0000000001).")
+	ch.expect(t, "chefe", "#embaralha adicionar aaaa", "A palavra aaaa não pode ser embaralhada.")
+	ch.expect(t, "chefe", "#embaralha adicionar palavra | cat | impossível", "Uso: #embaralha adicionar")
+	ch.expect(t, "chefe", "#embaralha adicionar a | b | medio | d | e", "Uso: #embaralha adicionar")
+
+	ch.expect(t, "chefe", "#embaralha total", "[Embaralha] 2 palavras, 2 ativas.")
+	ch.expect(t, "chefe", "#embaralha lista", "[Embaralha] 2 palavras: s0000000001 gaules, "+id+" Casimiro")
+	ch.expect(t, "chefe", "#embaralha lista streamers", "2 palavras")
+	ch.expect(t, "chefe", "#embaralha lista filmes", "[Embaralha] Nenhuma palavra encontrada.")
+
+	ch.expect(t, "chefe", "#embaralha desativar gaules", "[Embaralha] @chefe Palavra gaules desativada.")
+	ch.expect(t, "chefe", "#embaralha lista", "s0000000001 gaules (desativada)")
+	ch.expect(t, "chefe", "#embaralha total", "2 palavras, 1 ativas.")
+	ch.expect(t, "chefe", "#embaralha ativar s0000000001", "Palavra s0000000001 ativada.")
+	ch.expect(t, "chefe", "#embaralha ativar nada", "Palavra nada não encontrada.")
+
+	testenv.WriteJSON(t, ch.env.Path("scramble_words.json"), []types.ScrambleWord{
+		{ID: "s0000000001", Word: "gaules", Enabled: true},
+		{ID: "s0000000002", Word: "casimiro", Enabled: true},
+		{ID: "s0000000003", Word: "alanzoka", Enabled: false},
+	})
+	ch.expect(t, "chefe", "#embaralha recarregar", "[Embaralha] @chefe 3 palavras carregadas, 2 ativas.")
+
+	ch.expectSilence(t, "viewer", "#embaralha adicionar palavra")
+	ch.expectSilence(t, "viewer", "#embaralha lista")
+}
+
+func TestGamesQueueCommands(t *testing.T) {
+	withPermissions(t, PermissionConfig{})
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#jogos", "[Jogos] nenhum jogo em andamento")
+	ch.expect(t, "viewer", "#embaralha", "[Embaralha]")
+	ch.expect(t, "viewer", "#quiz", "[Jogos] @viewer quiz entrou na fila (posição 1).")
+	ch.expect(t, "viewer", "#games", "[Jogos] em andamento: embaralha | fila: quiz")
+
+	ch.expectSilence(t, "viewer", "#jogos limpar")
+	ch.expect(t, "mod", "#jogos limpar", "[Jogos] Fila limpa (1 removidos).", "moderator")
+	ch.expect(t, "viewer", "#jogos", "[Jogos] em andamento: embaralha")
+
+	// With the queue cleared, ending the scramble starts nothing.
+	ch.expect(t, "viewer", "gaules", "Você acertou")
+	if said := ch.advance(time.Minute); len(said) != 0 {
+		t.Fatalf("cleared queue still started a game: %q", said)
+	}
+}
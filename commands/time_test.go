@@ -0,0 +1,14 @@
+package commands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeUsesChannelClock(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#hora", "🕒 Agora são 12:00:00")
+	ch.clock.Advance(90*time.Minute + 5*time.Second)
+	ch.expect(t, "viewer", "#hora", "🕒 Agora são 13:30:05")
+}
@@ -1,37 +1,199 @@
 package commands
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"twitchgo/service"
+	"twitchgo/types"
 	"twitchgo/utils"
-
-	"github.com/gempir/go-twitch-irc/v4"
 )
 
-var triviaManager *service.TriviaManager
+func DefaultTriviaConfig() service.TriviaConfig {
+	return service.TriviaConfig{
+		RoundConfig: service.RoundConfig{
+			Cooldown:  10 * time.Second,
+			Timeout:   30 * time.Second,
+			MaxLength: 250,
+			Hints: service.HintConfig{
+				Stages: []service.HintStage{
+					{At: 0.33, Reveal: 0.2},
+					{At: 0.5, Reveal: 0.35},
+					{At: 0.66, Reveal: 0.5},
+				},
+				Penalty: 0.2,
+			},
+			Match: utils.DefaultTriviaMatchConfig(),
+			Reward: service.RewardConfig{
+				BasePoints:      8,
+				BonusPoints:     10,
+				BonusSimilarity: 0.92,
+			},
+		},
+		Difficulty: map[types.Difficulty]service.DifficultyScale{
+			types.DifficultyEasy:   {Reward: 0.5, Time: 0.8},
+			types.DifficultyMedium: {Reward: 1, Time: 1},
+			types.DifficultyHard:   {Reward: 2, Time: 1.5},
+		},
+		Tournament: service.TournamentConfig{
+			Break:       10 * time.Second,
+			MaxRounds:   20,
+			PodiumBonus: []int{50, 30, 15},
+		},
+	}
+}
 
-func init() {
-	database := utils.NewInMemoryTriviaDB()
+// Trivia starts a round. "#quiz <categoria>" only asks questions from that
+// category.
+func Trivia(ctx *ChatContext, args []string) error {
+	category := strings.Join(args, " ")
+	ctx.Channel.games.Request(ctx.Sender, ctx.Message, triviaGame, func() {
+		ctx.Channel.trivia.StartTrivia(ctx.Sender, ctx.Message, category)
+	})
+	return nil
+}
 
-	config := service.TriviaConfig{
-		Cooldown:  10 * time.Second,
-		HintTime:  20 * time.Second,
-		Timeout:   30 * time.Second,
-		MaxLength: 250,
+// TriviaTournament starts "#quiz torneio <n> [categoria]".
+func TriviaTournament(ctx *ChatContext, args []string) error {
+	rounds, err := strconv.Atoi(args[0])
+	if err != nil || rounds < 1 {
+		return ErrUsage
 	}
 
-	triviaManager = service.NewTriviaManager(database, config)
+	category := strings.Join(args[1:], " ")
+	ctx.Channel.games.Request(ctx.Sender, ctx.Message, triviaGame, func() {
+		ctx.Channel.trivia.StartTournament(ctx.Sender, ctx.Message, rounds, category)
+	})
+	return nil
+}
+
+func PauseTriviaTournament(ctx *ChatContext, args []string) error {
+	ctx.Channel.trivia.PauseTournament(ctx.Sender, ctx.Message)
+	return nil
 }
 
-func Trivia(client *twitch.Client, message twitch.PrivateMessage) {
-	triviaManager.StartTrivia(client, message)
+func ResumeTriviaTournament(ctx *ChatContext, args []string) error {
+	ctx.Channel.trivia.ResumeTournament(ctx.Sender, ctx.Message)
+	return nil
 }
 
-func StopTrivia(client *twitch.Client, message twitch.PrivateMessage) {
-	triviaManager.StopTrivia(client, message)
+func SkipTriviaRound(ctx *ChatContext, args []string) error {
+	ctx.Channel.trivia.SkipRound(ctx.Sender, ctx.Message)
+	return nil
 }
 
-func CheckTriviaAnswer(client *twitch.Client, message twitch.PrivateMessage) {
-	triviaManager.CheckAnswer(client, message, utils.CheckTriviaGuess)
+func EndTriviaTournament(ctx *ChatContext, args []string) error {
+	ctx.Channel.trivia.EndTournament(ctx.Sender, ctx.Message)
+	return nil
+}
+
+func TriviaCategories(ctx *ChatContext, args []string) error {
+	counts := triviaDB.GetCategoryCounts()
+	if len(counts) == 0 {
+		ctx.Say("[Quiz] Nenhuma categoria disponível.")
+		return nil
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s (%d)", name, counts[name])
+	}
+
+	ctx.Say(fmt.Sprintf("[Quiz] Categorias: %s", strings.Join(parts, ", ")))
+	return nil
+}
+
+func StopTrivia(ctx *ChatContext, args []string) error {
+	ctx.Channel.trivia.StopTrivia(ctx.Sender, ctx.Message)
+	return nil
+}
+
+// AddTriviaQuestion adds "<pergunta> | <resposta> [| <alternativa>...]" to the
+// pool and saves it.
+func AddTriviaQuestion(ctx *ChatContext, args []string) error {
+	parts := strings.Split(strings.Join(args, " "), "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ErrUsage
+	}
+
+	question := types.TriviaQuestion{Question: parts[0], Answer: parts[1], Enabled: true}
+	for _, alternative := range parts[2:] {
+		if alternative != "" {
+			question.Answers = append(question.Answers, alternative)
+		}
+	}
+
+	id := triviaDB.AddQuestion(question)
+	if err := saveTriviaQuestions(ctx); err != nil {
+		return err
+	}
+
+	ctx.Say(fmt.Sprintf("[Quiz] @%s Pergunta %s adicionada.", ctx.User().DisplayName, id))
+	return nil
+}
+
+func EnableTriviaQuestion(ctx *ChatContext, args []string) error {
+	return setTriviaQuestionEnabled(ctx, args[0], true)
+}
+
+func DisableTriviaQuestion(ctx *ChatContext, args []string) error {
+	return setTriviaQuestionEnabled(ctx, args[0], false)
+}
+
+func TriviaCounts(ctx *ChatContext, args []string) error {
+	ctx.Say(fmt.Sprintf("[Quiz] %d perguntas, %d ativas.",
+		triviaDB.GetQuestionCount(), triviaDB.GetEnabledQuestionCount()))
+	return nil
+}
+
+func ReloadTrivia(ctx *ChatContext, args []string) error {
+	if err := triviaDB.ReloadFromFile(); err != nil {
+		ctx.Say(fmt.Sprintf("[Quiz] @%s Não consegui recarregar as perguntas.", ctx.User().DisplayName))
+		return fmt.Errorf("reloading trivia questions: %w", err)
+	}
+
+	ctx.Say(fmt.Sprintf("[Quiz] @%s %d perguntas carregadas, %d ativas.",
+		ctx.User().DisplayName, triviaDB.GetQuestionCount(), triviaDB.GetEnabledQuestionCount()))
+	return nil
+}
+
+func setTriviaQuestionEnabled(ctx *ChatContext, id string, enabled bool) error {
+	var found bool
+	var state string
+	if enabled {
+		found, state = triviaDB.EnableQuestion(id), "ativada"
+	} else {
+		found, state = triviaDB.DisableQuestion(id), "desativada"
+	}
+
+	if !found {
+		ctx.Say(fmt.Sprintf("[Quiz] @%s Pergunta %s não encontrada.", ctx.User().DisplayName, id))
+		return nil
+	}
+	if err := saveTriviaQuestions(ctx); err != nil {
+		return err
+	}
+
+	ctx.Say(fmt.Sprintf("[Quiz] @%s Pergunta %s %s.", ctx.User().DisplayName, id, state))
+	return nil
+}
+
+func saveTriviaQuestions(ctx *ChatContext) error {
+	if err := triviaDB.SaveToJSONFile(utils.TriviaQuestionsFile); err != nil {
+		ctx.Say(fmt.Sprintf("[Quiz] @%s Não consegui salvar as perguntas.", ctx.User().DisplayName))
+		return fmt.Errorf("saving trivia questions: %w", err)
+	}
+	return nil
 }
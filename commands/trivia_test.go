@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"twitchgo/internal/testenv"
+	"twitchgo/types"
+)
+
+func TestTriviaStartAndStop(t *testing.T) {
+	withPermissions(t, PermissionConfig{})
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#quiz", "Qual a capital do Brasil?")
+	// Requests right after the start are taken as the same one.
+	ch.expectSilence(t, "outro", "#quiz")
+	ch.clock.Advance(6 * time.Second)
+	ch.expect(t, "outro", "#quiz", "[Quiz] @outro Quiz já está em andamento.")
+	ch.expectSilence(t, "viewer", "#quiz parar")
+	ch.expect(t, "mod", "#quiz parar", "[Quiz] MrDestructoid Quiz parou.", "moderator")
+
+	ch.clock.Advance(time.Minute)
+	ch.expect(t, "viewer", "#quiz", "Qual a capital do Brasil?")
+	ch.expect(t, "mod", "#paraquiz", "Quiz parou.", "moderator")
+	ch.expectSilence(t, "viewer", "brasilia")
+}
+
+func TestTriviaCategories(t *testing.T) {
+	ch := newTestChannel(t, nil)
+
+	ch.expect(t, "viewer", "#quiz categorias", "[Quiz] Categorias: geografia (1)")
+	ch.expect(t, "viewer", "#quiz geografia", "Qual a capital do Brasil?")
+}
+
+func TestTriviaManagement(t *testing.T) {
+	withPermissions(t, PermissionConfig{Admins: []string{"chefe"}})
+	ch := newTestChannel(t, nil)
+
+	replies := ch.send("chefe", "#quiz adicionar Qual o maior planeta? | Júpiter | jupiter")
+	added := regexp.MustCompile(`Pergunta (\S+) adicionada\.`).FindStringSubmatch(strings.Join(replies, "\n"))
+	if added == nil {
+		t.Fatalf("#quiz adicionar replied %q", replies)
+	}
+	id := added[1]
+
+	ch.expect(t, "chefe", "#quiz total", "[Quiz] 2 perguntas, 2 ativas.")
+	ch.expect(t, "chefe", "#quiz desativar t0000000001", "[Quiz] @chefe Pergunta t0000000001 desativada.")
+	ch.expect(t, "chefe", "#quiz total", "2 perguntas, 1 ativas.")
+	ch.expect(t, "viewer", "#quiz", "Qual o maior planeta?")
+	ch.expect(t, "viewer", "jupiter", "corretamente")
+	ch.expect(t, "chefe", "#quiz ativar t0000000001", "Pergunta t0000000001 ativada.")
+	ch.expect(t, "chefe", "#quiz desativar t9999999999", "Pergunta t9999999999 não encontrada.")
+
+	// Changes are saved to the pool file.
+	var saved []types.TriviaQuestion
+	data, err := os.ReadFile(ch.env.Path("trivia_questions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 2 || saved[1].ID != id || saved[1].Answer != "Júpiter" || len(saved[1].Answers) != 1 {
+		t.Fatalf("saved pool = %+v", saved)
+	}
+
+	testenv.WriteJSON(t, ch.env.Path("trivia_questions.json"), []types.TriviaQuestion{
+		{ID: "t0000000001", Question: "Qual a capital do Brasil?", Answer: "Brasília", Enabled: true},
+		{ID: "t0000000002", Question: "Quantos lados tem um triângulo?", Answer: "3", Enabled: false},
+	})
+	ch.expect(t, "chefe", "#quiz recarregar", "[Quiz] @chefe 2 perguntas carregadas, 1 ativas.")
+
+	ch.expect(t, "chefe", "#quiz adicionar Sem resposta", "Uso: #quiz adicionar <pergunta> | <resposta>")
+	ch.expect(t, "chefe", "#quiz adicionar | Resposta", "Uso: #quiz adicionar")
+	ch.expectSilence(t, "viewer", "#quiz adicionar Pergunta? | Resposta")
+	ch.expectSilence(t, "viewer", "#quiz total")
+}
+
+func TestTriviaTournamentControls(t *testing.T) {
+	withPermissions(t, PermissionConfig{Admins: []string{"chefe"}})
+	ch := newTestChannel(t, nil)
+
+	ch.expectAll(t, "viewer", "#quiz torneio 3", "Torneio de 3 perguntas começando", "[Quiz 1/3] Qual a capital do Brasil?")
+	ch.expectSilence(t, "viewer", "#quiz torneio pausar")
+	ch.expect(t, "chefe", "#quiz torneio pausar", "[Quiz] ⏸️ Torneio pausado.")
+
+	// The question running when paused is still played out.
+	ch.expectAll(t, "viewer", "brasilia", "corretamente", "Placar após 1/3")
+	if said := ch.advance(time.Minute); len(said) != 0 {
+		t.Fatalf("paused tournament went on: %q", said)
+	}
+
+	ch.expect(t, "chefe", "#quiz torneio continuar", "[Quiz] ▶️ Torneio retomado.")
+	if said := ch.advance(10 * time.Second); len(said) != 1 || !strings.Contains(said[0], "[Quiz 2/3]") {
+		t.Fatalf("after resuming: %q, want the second question", said)
+	}
+
+	ch.expectAll(t, "chefe", "#quiz torneio pular", "Pergunta pulada. A resposta era: Brasília", "Placar após 2/3")
+	// Skipping the break starts the next question right away.
+	ch.expect(t, "chefe", "#quiz torneio pular", "[Quiz 3/3]")
+	ch.expect(t, "chefe", "#quiz torneio encerrar", "Torneio encerrado! 🥇")
+
+	for _, control := range []string{"pausar", "continuar", "pular", "encerrar"} {
+		ch.expect(t, "chefe", "#quiz torneio "+control, "[Quiz] Nenhum torneio em andamento.")
+	}
+}
@@ -0,0 +1,235 @@
+// Package config reads the bot's settings file. The file holds defaults for
+// every channel, overrides for single channels and a few global settings;
+// anything it leaves out keeps its built-in value. Secrets such as the OAuth
+// token stay in the environment.
+//
+//	save_interval: 5m
+//	join: [somechannel, otherchannel]
+//	permissions:
+//	  admins: [someone]
+//	  denied_message: Você não pode usar esse comando.
+//	defaults:
+//	  timezone: America/Sao_Paulo
+//	  trivia:
+//	    timeout: 45s
+//	channels:
+//	  somechannel:
+//	    prefix: "!"
+//	    roulette:
+//	      win_odds: 0.45
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"twitchgo/commands"
+)
+
+// DefaultPath is where the bot looks for its settings unless told otherwise.
+const DefaultPath = "config.yaml"
+
+type Config struct {
+	// SaveInterval is how often the points of every channel are saved.
+	SaveInterval time.Duration
+	// Join lists the channels the bot joins on startup.
+	Join        []string
+	Permissions commands.PermissionConfig
+	defaults    yaml.Node
+	channels    map[string]yaml.Node
+}
+
+// Settings is one channel's section of the file: its commands.ChannelConfig
+// plus the timezone its daily rewards reset in.
+type Settings struct {
+	commands.ChannelConfig `yaml:",inline"`
+	Timezone               string `yaml:"timezone"`
+}
+
+// layout is the file as written. Decoding into it strictly is what catches
+// misspelled keys and values of the wrong type.
+type layout struct {
+	SaveInterval time.Duration             `yaml:"save_interval"`
+	Join         []string                  `yaml:"join"`
+	Permissions  commands.PermissionConfig `yaml:"permissions"`
+	Defaults     Settings                  `yaml:"defaults"`
+	Channels     map[string]Settings       `yaml:"channels"`
+}
+
+// raw keeps the sections as nodes so that only the keys present in the file
+// override anything.
+type raw struct {
+	SaveInterval time.Duration             `yaml:"save_interval"`
+	Join         []string                  `yaml:"join"`
+	Permissions  commands.PermissionConfig `yaml:"permissions"`
+	Defaults     yaml.Node                 `yaml:"defaults"`
+	Channels     map[string]yaml.Node      `yaml:"channels"`
+}
+
+// Default is the configuration used when there is no file.
+func Default() *Config {
+	return &Config{SaveInterval: 5 * time.Minute}
+}
+
+// Load reads the file at path. A missing file is reported as an error
+// wrapping fs.ErrNotExist.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func Parse(data []byte) (*Config, error) {
+	var strict layout
+	if err := decodeStrict(data, &strict); err != nil {
+		return nil, err
+	}
+
+	parsed := raw{SaveInterval: Default().SaveInterval}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+	if parsed.SaveInterval <= 0 {
+		return nil, fmt.Errorf("save_interval: must be positive, got %s", parsed.SaveInterval)
+	}
+
+	cfg := &Config{
+		SaveInterval: parsed.SaveInterval,
+		Join:         parsed.Join,
+		Permissions:  parsed.Permissions,
+		defaults:     parsed.Defaults,
+		channels:     make(map[string]yaml.Node),
+	}
+	for name, node := range parsed.Channels {
+		key := commands.NormalizeChannel(name)
+		if key == "" {
+			return nil, fmt.Errorf("channels: empty channel name")
+		}
+		if _, exists := cfg.channels[key]; exists {
+			return nil, fmt.Errorf("channels.%s: listed more than once", key)
+		}
+		cfg.channels[key] = node
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the global settings. Call it again after overriding them
+// from the environment.
+func (c *Config) Validate() error {
+	return errors.Join(validateGlobals(c.Join, c.Permissions)...)
+}
+
+// Defaults applies the file's defaults section to base.
+func (c *Config) Defaults(base commands.ChannelConfig) (commands.ChannelConfig, error) {
+	return merge(base, &c.defaults, "defaults")
+}
+
+// Channel returns the config for channel name: defaults moved to the
+// channel's data directory, then the channel's own overrides.
+func (c *Config) Channel(name string, defaults commands.ChannelConfig) (commands.ChannelConfig, error) {
+	config := defaults.ForChannel(name)
+	node := c.channels[config.Name]
+	return merge(config, &node, "channels."+config.Name)
+}
+
+// Overridden lists the channels the file has a section for.
+func (c *Config) Overridden() []string {
+	names := make([]string, 0, len(c.channels))
+	for name := range c.channels {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Print writes the effective configuration as YAML, in the file's own
+// layout.
+func (c *Config) Print(w io.Writer, defaults commands.ChannelConfig, channels []commands.ChannelConfig) error {
+	effective := layout{
+		SaveInterval: c.SaveInterval,
+		Join:         c.Join,
+		Permissions:  c.Permissions,
+		Defaults:     settingsFor(defaults),
+		Channels:     make(map[string]Settings, len(channels)),
+	}
+	for _, channel := range channels {
+		effective.Channels[channel.Name] = settingsFor(channel)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(effective); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// merge decodes node over a copy of base, so maps and slices in base are
+// never written to, and validates the result.
+func merge(base commands.ChannelConfig, node *yaml.Node, where string) (commands.ChannelConfig, error) {
+	data, err := yaml.Marshal(settingsFor(base))
+	if err != nil {
+		return base, fmt.Errorf("%s: %w", where, err)
+	}
+
+	var merged Settings
+	if err := yaml.Unmarshal(data, &merged); err != nil {
+		return base, fmt.Errorf("%s: %w", where, err)
+	}
+	if node.Kind != 0 {
+		if err := node.Decode(&merged); err != nil {
+			return base, fmt.Errorf("%s: %w", where, err)
+		}
+	}
+
+	config := merged.ChannelConfig
+	config.Name = base.Name
+	config.Clock = base.Clock
+
+	var errs []error
+	if merged.Timezone != "" {
+		location, err := time.LoadLocation(merged.Timezone)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s.timezone: unknown timezone %q", where, merged.Timezone))
+		}
+		config.Daily.Location = location
+	}
+	if config.Daily.Location == nil {
+		config.Daily.Location = time.Local
+	}
+
+	errs = append(errs, validate(where, config)...)
+	return config, errors.Join(errs...)
+}
+
+func settingsFor(config commands.ChannelConfig) Settings {
+	settings := Settings{ChannelConfig: config}
+	if config.Daily.Location != nil {
+		settings.Timezone = config.Daily.Location.String()
+	}
+	return settings
+}
+
+func decodeStrict(data []byte, out any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
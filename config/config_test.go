@@ -0,0 +1,242 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"twitchgo/commands"
+	"twitchgo/types"
+)
+
+func TestParseGlobals(t *testing.T) {
+	cfg, err := Parse([]byte(`
+join: [canal, "#Outro"]
+permissions:
+  admins: ["@Chefe"]
+  denied_message: Sem permissão.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"canal", "#Outro"}; !reflect.DeepEqual(cfg.Join, want) {
+		t.Errorf("Join = %q, want %q", cfg.Join, want)
+	}
+	want := commands.PermissionConfig{Admins: []string{"@Chefe"}, DeniedMessage: "Sem permissão."}
+	if !reflect.DeepEqual(cfg.Permissions, want) {
+		t.Errorf("Permissions = %+v, want %+v", cfg.Permissions, want)
+	}
+}
+
+func TestParseGlobalsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"empty channel", `join: [canal, " "]`, "join[1]: must not be empty"},
+		{"bad channel", `join: [canal legal]`, `join[0]: "canal legal" is not a valid Twitch name`},
+		{"duplicate channel", `join: [canal, "#CANAL"]`, "join[1]: canal is listed more than once"},
+		{"long channel", `join: [abcdefghijklmnopqrstuvwxyz]`, "longer than 25 characters"},
+		{"bad admin", "permissions:\n  admins: [chefe, \"@\"]", "permissions.admins[1]: must not be empty"},
+		{"multi-line message", "permissions:\n  denied_message: \"linha\\noutra\"", "permissions.denied_message: must fit on one line"},
+		{"long message", "permissions:\n  denied_message: " + strings.Repeat("a", 501), "must be at most 500 characters"},
+		{"misspelled key", "permissions:\n  admin: [chefe]", "field admin not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAfterOverride(t *testing.T) {
+	cfg, err := Parse([]byte(`join: [canal]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Join = append(cfg.Join, "Canal")
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("Validate accepted a channel listed twice")
+	}
+}
+
+func TestPrintGlobals(t *testing.T) {
+	cfg, err := Parse([]byte(`
+join: [canal]
+permissions:
+  admins: [chefe]
+  denied_message: Sem permissão.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := cfg.Print(&out, commands.DefaultChannelConfig(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The printed config reads back as the same settings.
+	printed, err := Parse(out.Bytes())
+	if err != nil {
+		t.Fatalf("printed config does not parse: %v\n%s", err, out.String())
+	}
+	if !reflect.DeepEqual(printed.Join, cfg.Join) || !reflect.DeepEqual(printed.Permissions, cfg.Permissions) {
+		t.Fatalf("printed config lost the global settings:\n%s", out.String())
+	}
+}
+
+const overridesYAML = `
+defaults:
+  timezone: America/Sao_Paulo
+  trivia:
+    timeout: 45s
+  roulette:
+    win_odds: 0.4
+channels:
+  "#Canal":
+    prefix: "!"
+    commands: [pontos, roleta]
+    roulette:
+      cooldown: 1m
+    trivia:
+      reward:
+        base_points: 20
+      difficulty:
+        dificil:
+          reward: 3
+          time: 1
+`
+
+func TestChannelOverridesMerge(t *testing.T) {
+	cfg, err := Parse([]byte(overridesYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtin := commands.DefaultChannelConfig()
+
+	defaults, err := cfg.Defaults(builtin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaults.Trivia.Timeout != 45*time.Second || defaults.Roulette.WinOdds != 0.4 {
+		t.Errorf("defaults: timeout %s, win odds %g; want 45s and 0.4", defaults.Trivia.Timeout, defaults.Roulette.WinOdds)
+	}
+	if defaults.Daily.Location.String() != "America/Sao_Paulo" {
+		t.Errorf("defaults timezone = %s", defaults.Daily.Location)
+	}
+	// Keys the file leaves out keep their built-in values.
+	if defaults.Prefix != builtin.Prefix || defaults.Trivia.Reward != builtin.Trivia.Reward || defaults.Roulette.Cooldown != builtin.Roulette.Cooldown {
+		t.Errorf("defaults lost built-in values: %+v", defaults)
+	}
+
+	channel, err := cfg.Channel("canal", defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channel.Name != "canal" || channel.Prefix != "!" || !reflect.DeepEqual(channel.Commands, []string{"pontos", "roleta"}) {
+		t.Errorf("channel: name %q, prefix %q, commands %q", channel.Name, channel.Prefix, channel.Commands)
+	}
+	if channel.Roulette.Cooldown != time.Minute || channel.Roulette.WinOdds != 0.4 {
+		t.Errorf("channel roulette = %+v, want the 1m cooldown over the defaults' 0.4 odds", channel.Roulette)
+	}
+	if channel.Trivia.Timeout != 45*time.Second || channel.Trivia.Reward.BasePoints != 20 ||
+		channel.Trivia.Reward.BonusPoints != builtin.Trivia.Reward.BonusPoints {
+		t.Errorf("channel trivia: timeout %s, reward %+v", channel.Trivia.Timeout, channel.Trivia.Reward)
+	}
+	if channel.Daily.Location.String() != "America/Sao_Paulo" {
+		t.Errorf("channel timezone = %s, want the defaults'", channel.Daily.Location)
+	}
+	if want := defaults.Points.ForChannel("canal"); channel.Points != want {
+		t.Errorf("channel points = %+v, want %+v", channel.Points, want)
+	}
+
+	// Merging never writes through to the defaults.
+	if len(defaults.Commands) != 0 || defaults.Trivia.Difficulty[types.DifficultyHard] != builtin.Trivia.Difficulty[types.DifficultyHard] {
+		t.Errorf("channel overrides leaked into the defaults: %+v", defaults)
+	}
+
+	other, err := cfg.Channel("outro", defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Prefix != defaults.Prefix || other.Roulette != defaults.Roulette || other.Trivia.Reward != defaults.Trivia.Reward {
+		t.Errorf("channel without a section differs from the defaults: %+v", other)
+	}
+
+	if overridden := cfg.Overridden(); !reflect.DeepEqual(overridden, []string{"canal"}) {
+		t.Errorf("Overridden() = %q, want [canal]", overridden)
+	}
+}
+
+func TestParseRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"top level", "save_intervall: 1m", "field save_intervall not found"},
+		{"defaults", "defaults:\n  trivia:\n    timout: 30s", "field timout not found"},
+		{"channel", "channels:\n  canal:\n    roulete:\n      win_odds: 0.5", "field roulete not found"},
+		{"wrong type", "defaults:\n  daily:\n    amount: muitos", "cannot unmarshal"},
+		{"duplicate channel", "channels:\n  Canal: {}\n  \"#canal\": {}", "channels.canal: listed more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Parse error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelValidationErrors(t *testing.T) {
+	cfg, err := Parse([]byte(`
+defaults:
+  trivia:
+    timeout: 0s
+channels:
+  canal:
+    prefix: " "
+    timezone: America/Gotham
+    roulette:
+      win_odds: 1.5
+    daily:
+      reset_hour: 24
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cfg.Defaults(commands.DefaultChannelConfig())
+	if err == nil || !strings.Contains(err.Error(), "defaults.trivia.timeout: must be positive, got 0s") {
+		t.Fatalf("Defaults error = %v", err)
+	}
+
+	// Every problem in the section is reported, each under its path.
+	_, err = cfg.Channel("canal", commands.DefaultChannelConfig())
+	if err == nil {
+		t.Fatal("Channel accepted an invalid section")
+	}
+	for _, want := range []string{
+		"channels.canal.prefix: must not be empty",
+		`channels.canal.timezone: unknown timezone "America/Gotham"`,
+		"channels.canal.roulette.win_odds: must be between 0 and 1, got 1.5",
+		"channels.canal.daily.reset_hour: must be between 0 and 23, got 24",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Channel error is missing %q:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"twitchgo/commands"
+	"twitchgo/service"
+	"twitchgo/types"
+	"twitchgo/utils"
+)
+
+// checker collects every problem with a config instead of stopping at the
+// first, naming each by its path in the file.
+type checker struct {
+	errs []error
+}
+
+func (c *checker) fail(path, format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+func (c *checker) positive(path string, d time.Duration) {
+	if d <= 0 {
+		c.fail(path, "must be positive, got %s", d)
+	}
+}
+
+func (c *checker) notNegative(path string, n int) {
+	if n < 0 {
+		c.fail(path, "must not be negative, got %d", n)
+	}
+}
+
+func (c *checker) notNegativeDuration(path string, d time.Duration) {
+	if d < 0 {
+		c.fail(path, "must not be negative, got %s", d)
+	}
+}
+
+func (c *checker) fraction(path string, f float64) {
+	if f < 0 || f > 1 {
+		c.fail(path, "must be between 0 and 1, got %g", f)
+	}
+}
+
+func (c *checker) difficulty(path string, d types.Difficulty) {
+	level, ok := types.ParseDifficulty(string(d))
+	if !ok || string(d) == "" {
+		c.fail(path, "unknown difficulty, use %s, %s or %s",
+			types.DifficultyEasy, types.DifficultyMedium, types.DifficultyHard)
+	} else if level != d {
+		c.fail(path, "write the difficulty as %q", level)
+	}
+}
+
+func validate(where string, config commands.ChannelConfig) []error {
+	c := &checker{}
+	at := func(path string) string { return where + "." + path }
+
+	if strings.TrimSpace(config.Prefix) == "" {
+		c.fail(at("prefix"), "must not be empty")
+	}
+
+	switch strings.ToLower(config.Points.Backend) {
+	case "", utils.PointsBackendJSON, utils.PointsBackendSQLite:
+	default:
+		c.fail(at("points.backend"), "unknown backend %q, use %s or %s",
+			config.Points.Backend, utils.PointsBackendJSON, utils.PointsBackendSQLite)
+	}
+	c.notNegative(at("points.backups"), config.Points.Backups)
+
+	validateRound(c, at("trivia"), config.Trivia.RoundConfig)
+	for level, scale := range config.Trivia.Difficulty {
+		validateScale(c, at("trivia.difficulty."+string(level)), level, scale)
+	}
+	tournament := config.Trivia.Tournament
+	c.notNegativeDuration(at("trivia.tournament.break"), tournament.Break)
+	if tournament.MaxRounds < 1 {
+		c.fail(at("trivia.tournament.max_rounds"), "must be at least 1, got %d", tournament.MaxRounds)
+	}
+	for i, bonus := range tournament.PodiumBonus {
+		c.notNegative(at(fmt.Sprintf("trivia.tournament.podium_bonus[%d]", i)), bonus)
+	}
+
+	validateRound(c, at("scramble"), config.Scramble.RoundConfig)
+	for level, scale := range config.Scramble.Difficulty {
+		validateScale(c, at("scramble.difficulty."+string(level)), level, scale)
+	}
+	for level, options := range config.Scramble.Shuffle {
+		path := at("scramble.shuffle." + string(level))
+		c.difficulty(path, level)
+		c.fraction(path+".shuffle", options.Shuffle)
+	}
+	c.notNegative(at("scramble.length.from"), config.Scramble.Length.From)
+	if config.Scramble.Length.PerLetter < 0 {
+		c.fail(at("scramble.length.per_letter"), "must not be negative, got %g", config.Scramble.Length.PerLetter)
+	}
+
+	c.notNegative(at("games.max_active"), config.Games.MaxActive)
+	c.notNegative(at("games.queue_size"), config.Games.QueueSize)
+	c.notNegativeDuration(at("games.queue_delay"), config.Games.QueueDelay)
+
+	c.notNegative(at("daily.amount"), config.Daily.Amount)
+	c.notNegative(at("daily.streak_bonus"), config.Daily.StreakBonus)
+	c.notNegative(at("daily.max_streak_bonus"), config.Daily.MaxStreakBonus)
+	c.positive(at("daily.period"), config.Daily.Period)
+	if config.Daily.ResetHour < 0 || config.Daily.ResetHour > 23 {
+		c.fail(at("daily.reset_hour"), "must be between 0 and 23, got %d", config.Daily.ResetHour)
+	}
+
+	c.fraction(at("roulette.win_odds"), config.Roulette.WinOdds)
+	c.notNegativeDuration(at("roulette.cooldown"), config.Roulette.Cooldown)
+
+	return c.errs
+}
+
+func validateRound(c *checker, where string, config service.RoundConfig) {
+	at := func(path string) string { return where + "." + path }
+
+	c.positive(at("timeout"), config.Timeout)
+	c.notNegativeDuration(at("cooldown"), config.Cooldown)
+	if config.MaxLength <= 0 {
+		c.fail(at("max_length"), "must be positive, got %d", config.MaxLength)
+	}
+
+	for i, stage := range config.Hints.Stages {
+		path := at(fmt.Sprintf("hints.stages[%d]", i))
+		if stage.At <= 0 || stage.At >= 1 {
+			c.fail(path+".at", "must be between 0 and 1 (exclusive), got %g", stage.At)
+		}
+		c.fraction(path+".reveal", stage.Reveal)
+	}
+	c.fraction(at("hints.penalty"), config.Hints.Penalty)
+
+	if config.Match.Accept <= 0 || config.Match.Accept > 1 {
+		c.fail(at("match.accept"), "must be above 0 and at most 1, got %g", config.Match.Accept)
+	}
+	if config.Match.Close < 0 || config.Match.Close > config.Match.Accept {
+		c.fail(at("match.close"), "must be between 0 and match.accept (%g), got %g", config.Match.Accept, config.Match.Close)
+	}
+
+	c.notNegative(at("reward.base_points"), config.Reward.BasePoints)
+	c.notNegative(at("reward.bonus_points"), config.Reward.BonusPoints)
+	c.fraction(at("reward.bonus_similarity"), config.Reward.BonusSimilarity)
+}
+
+func validateScale(c *checker, path string, level types.Difficulty, scale service.DifficultyScale) {
+	c.difficulty(path, level)
+	if scale.Reward < 0 {
+		c.fail(path+".reward", "must not be negative, got %g", scale.Reward)
+	}
+	if scale.Time <= 0 {
+		c.fail(path+".time", "must be positive, got %g", scale.Time)
+	}
+}
+
+// maxMessageLength is the longest chat message Twitch accepts.
+const maxMessageLength = 500
+
+func validateGlobals(join []string, permissions commands.PermissionConfig) []error {
+	c := &checker{}
+
+	seen := make(map[string]bool, len(join))
+	for i, name := range join {
+		path := fmt.Sprintf("join[%d]", i)
+		key := commands.NormalizeChannel(name)
+		c.username(path, key, name)
+		if seen[key] {
+			c.fail(path, "%s is listed more than once", key)
+		}
+		seen[key] = true
+	}
+
+	for i, admin := range permissions.Admins {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(admin, "@")))
+		c.username(fmt.Sprintf("permissions.admins[%d]", i), key, admin)
+	}
+
+	message := permissions.DeniedMessage
+	if strings.ContainsAny(message, "\r\n") {
+		c.fail("permissions.denied_message", "must fit on one line")
+	}
+	if n := utf8.RuneCountInString(message); n > maxMessageLength {
+		c.fail("permissions.denied_message", "must be at most %d characters, got %d", maxMessageLength, n)
+	}
+
+	return c.errs
+}
+
+// username checks a normalized Twitch login; given is the value as written.
+func (c *checker) username(path, name, given string) {
+	if name == "" {
+		c.fail(path, "must not be empty")
+		return
+	}
+	if len(name) > 25 {
+		c.fail(path, "%q is longer than 25 characters", given)
+		return
+	}
+	for _, char := range name {
+		if !(char >= 'a' && char <= 'z') && !(char >= '0' && char <= '9') && char != '_' {
+			c.fail(path, "%q is not a valid Twitch name", given)
+			return
+		}
+	}
+}
@@ -5,22 +5,27 @@ import (
 	"strings"
 
 	"twitchgo/commands"
-
-	"github.com/gempir/go-twitch-irc/v4"
+	"twitchgo/types"
 )
 
-func OnMessage(client *twitch.Client, message twitch.PrivateMessage, prefix string) {
-	log.Printf("[%s]: %s", message.User.Name, message.Message)
+func OnMessage(sender types.Sender, message types.ChatMessage) {
+	log.Printf("[#%s] [%s]: %s", message.Channel, message.User.Name, message.Text)
+
+	ch := commands.GetChannel(message.Channel)
+	if ch == nil {
+		return
+	}
+
+	ctx := commands.NewChatContext(sender, ch, message)
 
-	if strings.HasPrefix(message.Message, prefix) {
-		commands.Handle(client, message, prefix)
+	if strings.HasPrefix(message.Text, ch.Prefix) {
+		commands.Handle(ctx)
 		return
 	}
 
-	commands.CheckTriviaAnswer(client, message)
-	commands.CheckScrambleAnswer(client, message)
+	commands.CheckGameAnswers(ctx)
 
-	if strings.Contains(strings.ToLower(message.Message), "bot") {
-		client.Say(message.Channel, "👀 Chamou?")
+	if strings.Contains(strings.ToLower(message.Text), "bot") {
+		ctx.Say("👀 Chamou?")
 	}
 }
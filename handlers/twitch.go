@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"log"
+
+	"twitchgo/types"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// TwitchTransport adapts a go-twitch-irc client to types.Sender and
+// types.ChannelJoiner.
+type TwitchTransport struct {
+	client *twitch.Client
+}
+
+func NewTwitchTransport(client *twitch.Client) *TwitchTransport {
+	return &TwitchTransport{client: client}
+}
+
+func (t *TwitchTransport) Say(channel, text string) {
+	t.client.Say(channel, text)
+}
+
+func (t *TwitchTransport) Reply(channel, parentID, text string) {
+	if parentID == "" {
+		t.client.Say(channel, text)
+		return
+	}
+	t.client.Reply(channel, parentID, text)
+}
+
+// Whisper is dropped: Twitch no longer delivers whispers sent over IRC and
+// go-twitch-irc has no way to send them.
+func (t *TwitchTransport) Whisper(username, text string) {
+	log.Printf("Whispers are not supported over Twitch IRC, dropping whisper to %s: %s", username, text)
+}
+
+func (t *TwitchTransport) Join(channels ...string) {
+	t.client.Join(channels...)
+}
+
+func (t *TwitchTransport) Depart(channel string) {
+	t.client.Depart(channel)
+}
+
+func FromTwitch(message twitch.PrivateMessage) types.ChatMessage {
+	return types.ChatMessage{
+		ID:      message.ID,
+		Channel: message.Channel,
+		User: types.ChatUser{
+			ID:          message.User.ID,
+			Name:        message.User.Name,
+			DisplayName: message.User.DisplayName,
+			Badges:      message.User.Badges,
+		},
+		Text: message.Message,
+		Time: message.Time,
+	}
+}
+
+// Attach routes the client's chat messages to OnMessage.
+func Attach(client *twitch.Client) {
+	transport := NewTwitchTransport(client)
+	client.OnPrivateMessage(func(msg twitch.PrivateMessage) {
+		OnMessage(transport, FromTwitch(msg))
+	})
+}
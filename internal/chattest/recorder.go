@@ -0,0 +1,140 @@
+// Package chattest provides an in-memory chat transport for exercising
+// commands and games without a Twitch connection.
+package chattest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"twitchgo/types"
+)
+
+type Kind string
+
+const (
+	KindSay     Kind = "say"
+	KindReply   Kind = "reply"
+	KindWhisper Kind = "whisper"
+)
+
+type Sent struct {
+	Kind     Kind
+	Channel  string
+	ParentID string
+	Username string
+	Text     string
+}
+
+// Recorder implements types.Sender and types.ChannelJoiner by remembering
+// everything it is asked to do.
+type Recorder struct {
+	mutex  sync.Mutex
+	sent   []Sent
+	joined map[string]bool
+	nextID int
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		joined: make(map[string]bool),
+	}
+}
+
+func (r *Recorder) Say(channel, text string) {
+	r.record(Sent{Kind: KindSay, Channel: channel, Text: text})
+}
+
+func (r *Recorder) Reply(channel, parentID, text string) {
+	r.record(Sent{Kind: KindReply, Channel: channel, ParentID: parentID, Text: text})
+}
+
+func (r *Recorder) Whisper(username, text string) {
+	r.record(Sent{Kind: KindWhisper, Username: username, Text: text})
+}
+
+func (r *Recorder) Join(channels ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, channel := range channels {
+		r.joined[strings.ToLower(channel)] = true
+	}
+}
+
+func (r *Recorder) Depart(channel string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.joined, strings.ToLower(channel))
+}
+
+func (r *Recorder) Joined(channel string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.joined[strings.ToLower(channel)]
+}
+
+func (r *Recorder) Sent() []Sent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Sent(nil), r.sent...)
+}
+
+// Texts returns the text of every message sent to channel, in order.
+func (r *Recorder) Texts(channel string) []string {
+	var texts []string
+	for _, sent := range r.Sent() {
+		if sent.Channel == channel {
+			texts = append(texts, sent.Text)
+		}
+	}
+	return texts
+}
+
+func (r *Recorder) Last() (Sent, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.sent) == 0 {
+		return Sent{}, false
+	}
+	return r.sent[len(r.sent)-1], true
+}
+
+func (r *Recorder) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sent = nil
+}
+
+// Message builds an incoming chat message. Badges are given as names, such
+// as "moderator" or "broadcaster".
+func (r *Recorder) Message(channel, username, text string, badges ...string) types.ChatMessage {
+	r.mutex.Lock()
+	r.nextID++
+	id := r.nextID
+	r.mutex.Unlock()
+
+	badgeMap := make(map[string]int)
+	for _, badge := range badges {
+		badgeMap[badge] = 1
+	}
+
+	return types.ChatMessage{
+		ID:      fmt.Sprintf("msg-%d", id),
+		Channel: channel,
+		User: types.ChatUser{
+			ID:          "user-" + strings.ToLower(username),
+			Name:        strings.ToLower(username),
+			DisplayName: username,
+			Badges:      badgeMap,
+		},
+		Text: text,
+		Time: time.Now(),
+	}
+}
+
+func (r *Recorder) record(sent Sent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.sent = append(r.sent, sent)
+}
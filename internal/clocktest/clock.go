@@ -0,0 +1,113 @@
+// Package clocktest provides a types.Clock that only moves when told to.
+package clocktest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"twitchgo/types"
+)
+
+// Manual is a fake clock. Timers fire synchronously inside Advance, in the
+// order they are due, so a test sees their effects as soon as Advance
+// returns.
+type Manual struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+type timer struct {
+	clock *Manual
+	when  time.Time
+	fn    func()
+}
+
+func New(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+func (c *Manual) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *Manual) AfterFunc(d time.Duration, f func()) types.Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &timer{clock: c, when: c.now.Add(d), fn: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing every timer that comes due
+// along the way. Timers scheduled by those callbacks fire too if they fall
+// inside the window.
+func (c *Manual) Advance(d time.Duration) {
+	c.mutex.Lock()
+	target := c.now.Add(d)
+	c.mutex.Unlock()
+
+	for {
+		c.mutex.Lock()
+		next := c.popDue(target)
+		if next == nil {
+			c.now = target
+			c.mutex.Unlock()
+			return
+		}
+		c.now = next.when
+		c.mutex.Unlock()
+
+		next.fn()
+	}
+}
+
+// Set jumps to t, firing due timers like Advance. Moving backwards only
+// changes Now.
+func (c *Manual) Set(t time.Time) {
+	c.Advance(t.Sub(c.Now()))
+}
+
+// Pending is the number of timers that have not fired or been stopped.
+func (c *Manual) Pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+// popDue removes and returns the earliest timer due at or before target.
+func (c *Manual) popDue(target time.Time) *timer {
+	if len(c.timers) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].when.Before(c.timers[j].when)
+	})
+
+	first := c.timers[0]
+	if first.when.After(target) {
+		return nil
+	}
+
+	c.timers = c.timers[1:]
+	return first
+}
+
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
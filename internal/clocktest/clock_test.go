@@ -0,0 +1,103 @@
+package clocktest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAdvanceFiresInOrder(t *testing.T) {
+	clock := New(start)
+
+	var fired []string
+	var at []time.Duration
+	record := func(name string) func() {
+		return func() {
+			fired = append(fired, name)
+			at = append(at, clock.Now().Sub(start))
+		}
+	}
+
+	clock.AfterFunc(3*time.Second, record("c"))
+	clock.AfterFunc(time.Second, record("a"))
+	clock.AfterFunc(2*time.Second, record("b"))
+	clock.AfterFunc(5*time.Second, record("late"))
+
+	clock.Advance(3 * time.Second)
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(fired, want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+	// Callbacks see the time they were due at.
+	if want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}; !reflect.DeepEqual(at, want) {
+		t.Fatalf("fired at %v, want %v", at, want)
+	}
+	if got := clock.Now().Sub(start); got != 3*time.Second {
+		t.Fatalf("Now is %s past start, want 3s", got)
+	}
+	if clock.Pending() != 1 {
+		t.Fatalf("Pending = %d, want 1", clock.Pending())
+	}
+}
+
+func TestTimersScheduledByCallbacks(t *testing.T) {
+	clock := New(start)
+
+	fired := 0
+	clock.AfterFunc(time.Second, func() {
+		fired++
+		clock.AfterFunc(time.Second, func() { fired++ })
+		clock.AfterFunc(time.Minute, func() { fired++ })
+	})
+
+	clock.Advance(2 * time.Second)
+	if fired != 2 {
+		t.Fatalf("fired %d timers, want 2", fired)
+	}
+	if clock.Pending() != 1 {
+		t.Fatalf("Pending = %d, want 1", clock.Pending())
+	}
+}
+
+func TestStop(t *testing.T) {
+	clock := New(start)
+
+	fired := false
+	timer := clock.AfterFunc(time.Second, func() { fired = true })
+	if !timer.Stop() {
+		t.Fatal("Stop on a pending timer reported false")
+	}
+	if timer.Stop() {
+		t.Fatal("second Stop reported true")
+	}
+
+	clock.Advance(time.Minute)
+	if fired {
+		t.Fatal("stopped timer fired")
+	}
+
+	done := clock.AfterFunc(0, func() {})
+	clock.Advance(0)
+	if done.Stop() {
+		t.Fatal("Stop on a fired timer reported true")
+	}
+}
+
+func TestSet(t *testing.T) {
+	clock := New(start)
+
+	fired := false
+	clock.AfterFunc(time.Hour, func() { fired = true })
+
+	clock.Set(start.Add(-time.Hour))
+	if fired || !clock.Now().Equal(start.Add(-time.Hour)) {
+		t.Fatalf("moving back: fired = %v, Now = %s", fired, clock.Now())
+	}
+
+	clock.Set(start.Add(2 * time.Hour))
+	if !fired {
+		t.Fatal("timer did not fire when Set passed it")
+	}
+}
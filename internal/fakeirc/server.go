@@ -0,0 +1,367 @@
+// Package fakeirc is a local stand-in for Twitch's IRC server. It speaks
+// enough of the protocol for go-twitch-irc to log in, join channels and
+// exchange messages, and lets callers script the chat side.
+package fakeirc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// receiveTimeout is how long a PRIVMSG from the client waits for room in
+// the buffer Next reads from before the server gives up on it.
+var receiveTimeout = 5 * time.Second
+
+// ErrDropped is returned by Next once a message from the client was lost
+// because nobody read the ones before it.
+var ErrDropped = errors.New("messages from the client were dropped")
+
+// Line is a PRIVMSG sent by the connected client.
+type Line struct {
+	Raw     string
+	Tags    map[string]string
+	Channel string
+	Text    string
+}
+
+type Server struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	conns    map[*conn]struct{}
+	joined   map[string]bool
+	received chan Line
+	pongs    atomic.Int64
+	dropped  atomic.Int64
+	nextID   atomic.Int64
+	closed   chan struct{}
+}
+
+type conn struct {
+	net.Conn
+	writeMutex sync.Mutex
+	nick       string
+}
+
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{
+		listener: listener,
+		conns:    make(map[*conn]struct{}),
+		joined:   make(map[string]bool),
+		received: make(chan Line, 256),
+		closed:   make(chan struct{}),
+	}
+
+	go s.accept()
+	return s, nil
+}
+
+// Addr is the address to put in twitch.Client.IrcAddress (with TLS off).
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	select {
+	case <-s.closed:
+		return nil
+	default:
+		close(s.closed)
+	}
+
+	err := s.listener.Close()
+
+	s.mutex.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mutex.Unlock()
+
+	return err
+}
+
+// Privmsg delivers a chat message from user to channel. Badges are names such
+// as "moderator" or "subscriber".
+func (s *Server) Privmsg(channel, user, text string, badges ...string) {
+	channel = normalize(channel)
+	tags := s.userTags(channel, user, badges)
+	s.broadcast(fmt.Sprintf("@%s :%s!%s@%s.tmi.twitch.tv PRIVMSG #%s :%s",
+		encodeTags(tags), strings.ToLower(user), strings.ToLower(user), strings.ToLower(user), channel, text))
+}
+
+// UserNotice delivers a USERNOTICE such as a sub or raid. msgID is the
+// notice type, for example "sub" or "raid".
+func (s *Server) UserNotice(channel, user, msgID, systemMsg, text string) {
+	channel = normalize(channel)
+	tags := s.userTags(channel, user, nil)
+	tags["msg-id"] = msgID
+	tags["login"] = strings.ToLower(user)
+	tags["system-msg"] = systemMsg
+
+	line := fmt.Sprintf("@%s :tmi.twitch.tv USERNOTICE #%s", encodeTags(tags), channel)
+	if text != "" {
+		line += " :" + text
+	}
+	s.broadcast(line)
+}
+
+// Ping sends a server PING. Pongs reports how many PONGs came back.
+func (s *Server) Ping() {
+	s.broadcast("PING :tmi.twitch.tv")
+}
+
+func (s *Server) Pongs() int {
+	return int(s.pongs.Load())
+}
+
+func (s *Server) Joined(channel string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.joined[normalize(channel)]
+}
+
+func (s *Server) WaitJoined(channel string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for !s.Joined(channel) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("client did not join #%s within %s", normalize(channel), timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return nil
+}
+
+// Next returns the next PRIVMSG sent by the client. It fails with
+// ErrDropped from the first message lost onwards.
+func (s *Server) Next(timeout time.Duration) (Line, error) {
+	if n := s.dropped.Load(); n > 0 {
+		return Line{}, fmt.Errorf("%w: %d lost", ErrDropped, n)
+	}
+
+	select {
+	case line := <-s.received:
+		return line, nil
+	case <-time.After(timeout):
+		return Line{}, errors.New("no message received")
+	case <-s.closed:
+		return Line{}, errors.New("server closed")
+	}
+}
+
+func (s *Server) accept() {
+	for {
+		raw, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		c := &conn{Conn: raw}
+		s.mutex.Lock()
+		s.conns[c] = struct{}{}
+		s.mutex.Unlock()
+
+		go s.serve(c)
+	}
+}
+
+func (s *Server) serve(c *conn) {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.conns, c)
+		s.mutex.Unlock()
+		c.Close()
+	}()
+
+	scanner := bufio.NewScanner(c)
+	for scanner.Scan() {
+		s.handle(c, strings.TrimRight(scanner.Text(), "\r"))
+	}
+}
+
+func (s *Server) handle(c *conn, raw string) {
+	tags, rest := splitTags(raw)
+	command, params, trailing := splitCommand(rest)
+
+	switch command {
+	case "CAP":
+		if len(params) > 0 && params[0] == "REQ" {
+			c.send(":tmi.twitch.tv CAP * ACK :" + trailing)
+		}
+
+	case "PASS":
+
+	case "NICK":
+		if len(params) > 0 {
+			c.nick = strings.ToLower(params[0])
+		}
+		for _, line := range []string{
+			":tmi.twitch.tv 001 %s :Welcome, GLHF!",
+			":tmi.twitch.tv 002 %s :Your host is tmi.twitch.tv",
+			":tmi.twitch.tv 003 %s :This server is rather new",
+			":tmi.twitch.tv 004 %s :-",
+			":tmi.twitch.tv 375 %s :-",
+			":tmi.twitch.tv 372 %s :You are in a maze of twisty passages, all alike.",
+			":tmi.twitch.tv 376 %s :>",
+		} {
+			c.send(fmt.Sprintf(line, c.nick))
+		}
+
+	case "JOIN":
+		for _, channel := range strings.Split(firstParam(params, trailing), ",") {
+			channel = normalize(channel)
+			if channel == "" {
+				continue
+			}
+			s.mutex.Lock()
+			s.joined[channel] = true
+			s.mutex.Unlock()
+
+			c.send(fmt.Sprintf(":%s!%s@%s.tmi.twitch.tv JOIN #%s", c.nick, c.nick, c.nick, channel))
+			c.send(fmt.Sprintf("@emote-only=0;followers-only=-1;r9k=0;room-id=1;slow=0;subs-only=0 :tmi.twitch.tv ROOMSTATE #%s", channel))
+		}
+
+	case "PART":
+		channel := normalize(firstParam(params, trailing))
+		s.mutex.Lock()
+		delete(s.joined, channel)
+		s.mutex.Unlock()
+		c.send(fmt.Sprintf(":%s!%s@%s.tmi.twitch.tv PART #%s", c.nick, c.nick, c.nick, channel))
+
+	case "PING":
+		c.send(":tmi.twitch.tv PONG tmi.twitch.tv :" + trailing)
+
+	case "PONG":
+		s.pongs.Add(1)
+
+	case "PRIVMSG":
+		if len(params) == 0 {
+			return
+		}
+		// Blocking holds up the client's reads rather than losing a line
+		// a test is waiting for.
+		select {
+		case s.received <- Line{Raw: raw, Tags: tags, Channel: normalize(params[0]), Text: trailing}:
+		case <-s.closed:
+		case <-time.After(receiveTimeout):
+			s.dropped.Add(1)
+		}
+	}
+}
+
+func (s *Server) broadcast(line string) {
+	s.mutex.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+
+	for _, c := range conns {
+		c.send(line)
+	}
+}
+
+func (s *Server) userTags(channel, user string, badges []string) map[string]string {
+	login := strings.ToLower(user)
+	id := s.nextID.Add(1)
+
+	var badgeList []string
+	tags := map[string]string{
+		"badge-info":   "",
+		"color":        "",
+		"display-name": user,
+		"emotes":       "",
+		"flags":        "",
+		"id":           fmt.Sprintf("00000000-0000-0000-0000-%012d", id),
+		"mod":          "0",
+		"room-id":      "1",
+		"subscriber":   "0",
+		"tmi-sent-ts":  fmt.Sprint(time.Now().UnixMilli()),
+		"turbo":        "0",
+		"user-id":      fmt.Sprint(1000 + len(login)),
+		"user-type":    "",
+	}
+	for _, badge := range badges {
+		badgeList = append(badgeList, badge+"/1")
+		switch badge {
+		case "moderator":
+			tags["mod"] = "1"
+			tags["user-type"] = "mod"
+		case "subscriber":
+			tags["subscriber"] = "1"
+		}
+	}
+	tags["badges"] = strings.Join(badgeList, ",")
+
+	return tags
+}
+
+func (c *conn) send(line string) {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	c.Write([]byte(line + "\r\n"))
+}
+
+func splitTags(raw string) (map[string]string, string) {
+	tags := make(map[string]string)
+	if !strings.HasPrefix(raw, "@") {
+		return tags, raw
+	}
+
+	tagPart, rest, _ := strings.Cut(raw[1:], " ")
+	for _, tag := range strings.Split(tagPart, ";") {
+		key, value, _ := strings.Cut(tag, "=")
+		tags[key] = value
+	}
+	return tags, rest
+}
+
+func splitCommand(line string) (string, []string, string) {
+	if strings.HasPrefix(line, ":") {
+		_, line, _ = strings.Cut(line, " ")
+	}
+
+	head, trailing, _ := strings.Cut(line, " :")
+	fields := strings.Fields(head)
+	if len(fields) == 0 {
+		return "", nil, trailing
+	}
+	return strings.ToUpper(fields[0]), fields[1:], trailing
+}
+
+func firstParam(params []string, trailing string) string {
+	if len(params) > 0 {
+		return params[0]
+	}
+	return trailing
+}
+
+func encodeTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escaper := strings.NewReplacer(`\`, `\\`, ";", `\:`, " ", `\s`, "\r", `\r`, "\n", `\n`)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+escaper.Replace(tags[key]))
+	}
+	return strings.Join(parts, ";")
+}
+
+func normalize(channel string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(channel), "#"))
+}
@@ -0,0 +1,120 @@
+package fakeirc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+const timeout = 2 * time.Second
+
+// connect starts a server and logs a go-twitch-irc client into #canal.
+func connect(t *testing.T, setup func(*twitch.Client)) *Server {
+	t.Helper()
+
+	s, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	client := twitch.NewClient("bot", "oauth:test")
+	client.IrcAddress = s.Addr()
+	client.TLS = false
+	if setup != nil {
+		setup(client)
+	}
+	client.Join("canal")
+	go client.Connect()
+	t.Cleanup(func() { client.Disconnect() })
+
+	if err := s.WaitJoined("canal", timeout); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPingIsAnswered(t *testing.T) {
+	s := connect(t, nil)
+
+	s.Ping()
+	s.Ping()
+
+	deadline := time.Now().Add(timeout)
+	for s.Pongs() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pongs = %d after two pings, want 2", s.Pongs())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestUserNotice(t *testing.T) {
+	notices := make(chan twitch.UserNoticeMessage, 2)
+	s := connect(t, func(client *twitch.Client) {
+		client.OnUserNoticeMessage(func(message twitch.UserNoticeMessage) { notices <- message })
+	})
+
+	s.UserNotice("#Canal", "Amigo", "raid", "5 raiders from Amigo have joined!", "")
+	s.UserNotice("canal", "Viewer", "resub", "Viewer subscribed for 3 months!", "três meses")
+
+	tests := []struct {
+		user, msgID, systemMsg, text string
+	}{
+		{"Amigo", "raid", "5 raiders from Amigo have joined!", ""},
+		{"Viewer", "resub", "Viewer subscribed for 3 months!", "três meses"},
+	}
+	for _, tt := range tests {
+		select {
+		case notice := <-notices:
+			if notice.Channel != "canal" || notice.User.DisplayName != tt.user || notice.MsgID != tt.msgID ||
+				notice.SystemMsg != tt.systemMsg || notice.Message != tt.text {
+				t.Errorf("notice = #%s %s %s %q %q, want #canal %s %s %q %q",
+					notice.Channel, notice.User.DisplayName, notice.MsgID, notice.SystemMsg, notice.Message,
+					tt.user, tt.msgID, tt.systemMsg, tt.text)
+			}
+		case <-time.After(timeout):
+			t.Fatalf("no USERNOTICE for %s", tt.user)
+		}
+	}
+}
+
+// TestPrivmsgOverflowFails fills the buffer Next reads from. The line that
+// doesn't fit must make Next fail instead of going missing.
+func TestPrivmsgOverflowFails(t *testing.T) {
+	defer func(d time.Duration) { receiveTimeout = d }(receiveTimeout)
+	receiveTimeout = 10 * time.Millisecond
+
+	s, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	c, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for i := 0; i <= cap(s.received); i++ {
+		if _, err := fmt.Fprintf(c, "PRIVMSG #canal :mensagem %d\r\n", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for s.dropped.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the line past the buffer was neither delivered nor reported")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := s.Next(timeout); !errors.Is(err, ErrDropped) {
+		t.Fatalf("Next() err = %v, want %v", err, ErrDropped)
+	}
+}
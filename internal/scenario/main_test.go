@@ -0,0 +1,11 @@
+package scenario_test
+
+import (
+	"testing"
+
+	"twitchgo/internal/testenv"
+)
+
+func TestMain(m *testing.M) {
+	testenv.Main(m)
+}
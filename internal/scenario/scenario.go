@@ -0,0 +1,256 @@
+// Package scenario runs the whole bot against a fakeirc server so chat
+// sessions can be scripted end to end: the real go-twitch-irc client, the
+// router, the games and the points storage all take part.
+//
+// Game timers, cooldowns and daily rewards run on a manual clock that only
+// moves through Advance.
+//
+// Channels live in the commands package registry, so only one Harness can run
+// per process at a time.
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+
+	"twitchgo/commands"
+	"twitchgo/handlers"
+	"twitchgo/internal/clocktest"
+	"twitchgo/internal/fakeirc"
+	"twitchgo/internal/testenv"
+)
+
+const DefaultTimeout = 2 * time.Second
+
+type Options struct {
+	Nick     string
+	Channels []string
+	// Defaults is the config every channel starts from. Zero value means
+	// commands.DefaultChannelConfig().
+	Defaults *commands.ChannelConfig
+	// DataDir holds the points files and the trivia and scramble pools, so
+	// nothing is written to the bot's data/. Start requires it; New makes one
+	// with testenv when it is empty.
+	DataDir string
+	// Start is where the clock starts; zero means testenv.Epoch.
+	Start time.Time
+	// Timeout bounds how long Expect waits for the bot, in real time.
+	Timeout time.Duration
+}
+
+type Harness struct {
+	Server   *fakeirc.Server
+	Client   *twitch.Client
+	Clock    *clocktest.Manual
+	channels []string
+	timeout  time.Duration
+	done     chan error
+	restore  func()
+}
+
+func Start(opts Options) (*Harness, error) {
+	if opts.Nick == "" {
+		opts.Nick = "twitchgo"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.Start.IsZero() {
+		opts.Start = testenv.Epoch
+	}
+	if len(opts.Channels) == 0 {
+		return nil, fmt.Errorf("no channels to join")
+	}
+	if opts.DataDir == "" {
+		return nil, fmt.Errorf("no data directory")
+	}
+
+	defaults := commands.DefaultChannelConfig()
+	if opts.Defaults != nil {
+		defaults = *opts.Defaults
+	}
+	defaults.Points.JSONPath = filepath.Join(opts.DataDir, "user_data.json")
+	defaults.Points.SQLitePath = filepath.Join(opts.DataDir, "points.db")
+	defaults.Points.LedgerPath = filepath.Join(opts.DataDir, "ledger.jsonl")
+
+	restore := testenv.UsePools(opts.DataDir)
+
+	clock := clocktest.New(opts.Start)
+	defaults.Clock = clock
+
+	commands.Setup(defaults)
+
+	server, err := fakeirc.Start()
+	if err != nil {
+		restore()
+		return nil, err
+	}
+
+	h := &Harness{
+		Server:  server,
+		Clock:   clock,
+		timeout: opts.Timeout,
+		done:    make(chan error, 1),
+		restore: restore,
+	}
+
+	for _, name := range opts.Channels {
+		if _, err := commands.AddChannel(defaults.ForChannel(name)); err != nil {
+			h.Close()
+			return nil, err
+		}
+		h.channels = append(h.channels, commands.NormalizeChannel(name))
+	}
+
+	h.Client = twitch.NewClient(opts.Nick, "oauth:scenario")
+	h.Client.IrcAddress = server.Addr()
+	h.Client.TLS = false
+	handlers.Attach(h.Client)
+	h.Client.Join(h.channels...)
+
+	go func() { h.done <- h.Client.Connect() }()
+
+	for _, name := range h.channels {
+		if err := server.WaitJoined(name, h.timeout); err != nil {
+			h.Close()
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// New starts a harness for t and closes it when the test ends.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+
+	if opts.DataDir == "" {
+		opts.DataDir = testenv.New(t).Dir
+	}
+	h, err := Start(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := h.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return h
+}
+
+// Close disconnects the client, stops the server and removes the channels
+// from the registry, saving their points.
+func (h *Harness) Close() error {
+	if h.Client != nil {
+		h.Client.Disconnect()
+	}
+	h.Server.Close()
+
+	var firstErr error
+	for _, name := range h.channels {
+		if err := commands.RemoveChannel(name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	h.channels = nil
+	h.restore()
+	return firstErr
+}
+
+// Advance moves the bot's clock forward, firing any game timers due.
+func (h *Harness) Advance(d time.Duration) {
+	h.Clock.Advance(d)
+}
+
+// Chat sends text to channel as user. Badges are names such as "moderator".
+func (h *Harness) Chat(channel, user, text string, badges ...string) {
+	h.Server.Privmsg(channel, user, text, badges...)
+}
+
+// Expect waits for the bot to say something in channel containing substr.
+// Messages that don't match are skipped.
+func (h *Harness) Expect(channel, substr string) (string, error) {
+	channel = commands.NormalizeChannel(channel)
+	deadline := time.Now().Add(h.timeout)
+
+	var seen []string
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return "", fmt.Errorf("#%s: no message containing %q (saw %q)", channel, substr, seen)
+		}
+
+		line, err := h.Server.Next(remaining)
+		if err != nil {
+			return "", fmt.Errorf("#%s: no message containing %q (saw %q): %w", channel, substr, seen, err)
+		}
+		if line.Channel == channel && strings.Contains(line.Text, substr) {
+			return line.Text, nil
+		}
+		seen = append(seen, line.Text)
+	}
+}
+
+// ExpectSilence fails if the bot says anything within window.
+func (h *Harness) ExpectSilence(window time.Duration) error {
+	line, err := h.Server.Next(window)
+	if err == nil {
+		return fmt.Errorf("#%s: unexpected message %q", line.Channel, line.Text)
+	}
+	if errors.Is(err, fakeirc.ErrDropped) {
+		return err
+	}
+	return nil
+}
+
+// Step is one line of a scripted chat. Advance moves the clock before the
+// line is sent; a step with no Text only moves the clock. When Expect is set
+// the bot must answer with a message containing it before the next step runs.
+type Step struct {
+	Advance time.Duration
+	Channel string
+	User    string
+	Badges  []string
+	Text    string
+	Expect  string
+}
+
+// Run plays steps in order. Steps without a channel use the first one joined.
+func (h *Harness) Run(steps []Step) error {
+	for i, step := range steps {
+		channel := step.Channel
+		if channel == "" {
+			channel = h.channels[0]
+		}
+
+		if step.Advance > 0 {
+			h.Advance(step.Advance)
+		}
+		if step.Text != "" {
+			h.Chat(channel, step.User, step.Text, step.Badges...)
+		}
+
+		if step.Expect == "" {
+			continue
+		}
+		if _, err := h.Expect(channel, step.Expect); err != nil {
+			return fmt.Errorf("step %d (%s: %s): %w", i+1, step.User, step.Text, err)
+		}
+	}
+	return nil
+}
+
+// Play runs steps and fails t at the first one that goes wrong.
+func (h *Harness) Play(t testing.TB, steps []Step) {
+	t.Helper()
+	if err := h.Run(steps); err != nil {
+		t.Fatal(err)
+	}
+}
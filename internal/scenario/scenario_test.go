@@ -0,0 +1,155 @@
+package scenario_test
+
+import (
+	"testing"
+	"time"
+
+	"twitchgo/commands"
+	"twitchgo/internal/scenario"
+)
+
+const channel = "canal"
+
+// start runs a harness over the testenv pools. configure, when given, edits
+// the channel defaults first.
+func start(t *testing.T, configure func(*commands.ChannelConfig)) *scenario.Harness {
+	t.Helper()
+
+	defaults := commands.DefaultChannelConfig()
+	defaults.Daily.Location = time.UTC
+	if configure != nil {
+		configure(&defaults)
+	}
+	return scenario.New(t, scenario.Options{Channels: []string{channel}, Defaults: &defaults})
+}
+
+func TestTriviaCorrectAnswer(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#quiz", Expect: "Qual a capital do Brasil?"},
+		{User: "viewer", Text: "brasil", Expect: "brasil está perto"},
+		{User: "viewer", Text: "brasilia", Expect: "respondeu à pergunta corretamente e ganhou 10 pontos"},
+		{User: "viewer", Text: "#pontos", Expect: "Você tem 10 pontos"},
+	})
+}
+
+func TestTriviaHintsAndTimeout(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#quiz", Expect: "Qual a capital do Brasil?"},
+		{Advance: 10 * time.Second, Expect: "[Quiz] Dica:"},
+		{Advance: 5 * time.Second, Expect: "[Quiz] Dica:"},
+		{Advance: 5 * time.Second, Expect: "[Quiz] Dica:"},
+		{Advance: 10 * time.Second, Expect: "Ninguém respondeu corretamente. Madge A resposta era: Brasília"},
+		{User: "viewer", Text: "brasilia"},
+	})
+	if err := h.ExpectSilence(200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScramble(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#embaralha", Expect: "[Embaralha]"},
+		{User: "viewer", Text: "gaules", Expect: "Você acertou"},
+		{User: "viewer", Text: "#pontos", Expect: "Você tem"},
+	})
+}
+
+func TestRouletteWin(t *testing.T) {
+	h := start(t, func(c *commands.ChannelConfig) { c.Roulette.WinOdds = 1 })
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#roleta 10", Expect: "Você não tem nenhum ponto"},
+		{Advance: 5 * time.Second, User: "dono", Badges: []string{"broadcaster"}, Text: "#addpontos viewer 100", Expect: "Adicionou 100 pontos a viewer"},
+		{User: "viewer", Text: "#roleta 50", Expect: "Você ganhou 50 pontos e agora tem 150 pontos"},
+		{User: "viewer", Text: "#roleta all"},
+	})
+	// The roulette cooldown swallows the second wager.
+	if err := h.ExpectSilence(200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Play(t, []scenario.Step{
+		{Advance: 5 * time.Second, User: "viewer", Text: "#roleta all", Expect: "Você ganhou 150 pontos e agora tem 300 pontos"},
+	})
+}
+
+func TestRouletteLose(t *testing.T) {
+	h := start(t, func(c *commands.ChannelConfig) { c.Roulette.WinOdds = 0 })
+
+	h.Play(t, []scenario.Step{
+		{User: "dono", Badges: []string{"broadcaster"}, Text: "#addpontos viewer 100", Expect: "Adicionou 100"},
+		// Every wager starts the cooldown, even one that is turned down.
+		{User: "viewer", Text: "#roleta 0", Expect: "acabou de tentar apostar 0 pontos"},
+		{Advance: 5 * time.Second, User: "viewer", Text: "#roleta 101%", Expect: "mais de 100%"},
+		{Advance: 5 * time.Second, User: "viewer", Text: "#roleta 500", Expect: "Você não tem pontos suficientes"},
+		{Advance: 5 * time.Second, User: "viewer", Text: "#roleta 50%", Expect: "Você perdeu 50 pontos e agora tem 50 pontos"},
+		{User: "viewer", Text: "#topperda", Expect: "viewer"},
+	})
+}
+
+func TestDailyAndDonations(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#diario", Expect: "Você recebeu 50 pontos diários! Novo saldo: 50"},
+		{User: "viewer", Text: "#diario", Expect: "Você já resgatou seus pontos"},
+		{User: "viewer", Text: "#doar amigo 500", Expect: "Você não pode doar mais pontos do que tem"},
+		{User: "viewer", Text: "#doar amigo 30", Expect: "Doou 30 pontos para amigo"},
+		{User: "amigo", Text: "#pontos", Expect: "Você tem 30 pontos"},
+		{Advance: 24 * time.Hour, User: "viewer", Text: "#diario", Expect: "sequência de 2 dias"},
+		{User: "viewer", Text: "#extrato", Expect: "[Extrato] @viewer"},
+		{User: "dono", Badges: []string{"broadcaster"}, Text: "#auditar viewer", Expect: "saldo pelo extrato 80, saldo atual 80"},
+	})
+}
+
+func TestRouterUsageAndPermissions(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#roleta", Expect: "Uso: #roleta <quantia|porcentagem%|all>"},
+		{User: "viewer", Text: "#doar amigo", Expect: "Uso: #doar <usuario> <quantia>"},
+		{User: "viewer", Text: "#addpontos viewer 100"},
+	})
+	// Denied commands are dropped silently unless a message is configured.
+	if err := h.ExpectSilence(200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#pontos", Expect: "Você tem 0 pontos"},
+		{User: "viewer", Text: "#bot", Expect: "Olá!"},
+	})
+}
+
+func TestGamesQueue(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#embaralha", Expect: "[Embaralha]"},
+		{User: "viewer", Text: "#quiz", Expect: "quiz entrou na fila (posição 1)"},
+		{User: "viewer", Text: "#jogos", Expect: "em andamento: embaralha | fila: quiz"},
+		{User: "viewer", Text: "gaules", Expect: "Você acertou"},
+		{Advance: 5 * time.Second, Expect: "Qual a capital do Brasil?"},
+		{User: "mod", Badges: []string{"moderator"}, Text: "#quiz parar", Expect: "Quiz parou"},
+		{User: "viewer", Text: "#jogos", Expect: "nenhum jogo em andamento"},
+	})
+}
+
+func TestTournament(t *testing.T) {
+	h := start(t, nil)
+
+	h.Play(t, []scenario.Step{
+		{User: "viewer", Text: "#quiz torneio 2", Expect: "[Quiz 1/2] Qual a capital do Brasil?"},
+		{User: "viewer", Text: "brasilia", Expect: "corretamente"},
+		{Expect: "Placar após 1/2"},
+		{Advance: 10 * time.Second, Expect: "[Quiz 2/2] Qual a capital do Brasil?"},
+		{User: "amigo", Text: "brasilia", Expect: "corretamente"},
+		{Expect: "Torneio encerrado! 🥇"},
+	})
+}
@@ -0,0 +1,112 @@
+// Package testenv is the setup the package tests share: a quiet log and a
+// temporary data directory holding small trivia and scramble pools, with a
+// manual clock and a chat recorder to drive the code under test.
+//
+// The pool files are package variables in utils, so tests using an Env must
+// not run in parallel.
+package testenv
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"twitchgo/internal/chattest"
+	"twitchgo/internal/clocktest"
+	"twitchgo/types"
+	"twitchgo/utils"
+)
+
+// Main runs the tests of a package with logging discarded. Call it from the
+// package's TestMain.
+func Main(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// Epoch is where the clock of every Env starts: noon, so a daily claim made
+// right away leaves half a day until midnight.
+var Epoch = time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Questions and Words are the pools New writes.
+var (
+	Questions = []types.TriviaQuestion{
+		{ID: "t0000000001", Question: "Qual a capital do Brasil?", Answer: "Brasília", Category: "geografia", Enabled: true},
+	}
+	Words = []types.ScrambleWord{
+		{ID: "s0000000001", Word: "gaules", Category: "streamers", Enabled: true},
+	}
+)
+
+type Env struct {
+	// Dir is the data directory; points files go under it too.
+	Dir   string
+	Clock *clocktest.Manual
+	Chat  *chattest.Recorder
+}
+
+// New makes an Env in a temporary directory and points the trivia and
+// scramble files at it until the test ends.
+func New(t testing.TB) *Env {
+	t.Helper()
+
+	dir := t.TempDir()
+	WriteJSON(t, filepath.Join(dir, "trivia_questions.json"), Questions)
+	WriteJSON(t, filepath.Join(dir, "scramble_words.json"), Words)
+	t.Cleanup(UsePools(dir))
+
+	return &Env{
+		Dir:   dir,
+		Clock: clocktest.New(Epoch),
+		Chat:  chattest.NewRecorder(),
+	}
+}
+
+// Path joins name onto the data directory.
+func (e *Env) Path(name string) string {
+	return filepath.Join(e.Dir, name)
+}
+
+// Points opens a JSON points database in the data directory, closed when
+// the test ends.
+func (e *Env) Points(t testing.TB) *utils.InMemoryPointsDB {
+	t.Helper()
+	db, err := utils.NewInMemoryPointsDB(e.Path("user_data.json"), e.Path("ledger.jsonl"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// UsePools points the trivia and scramble files at dir and returns a
+// function that points them back.
+func UsePools(dir string) func() {
+	questions, questionHistory := utils.TriviaQuestionsFile, utils.TriviaHistoryFile
+	words, wordHistory := utils.ScrambleWordsFile, utils.ScrambleHistoryFile
+
+	utils.TriviaQuestionsFile = filepath.Join(dir, "trivia_questions.json")
+	utils.TriviaHistoryFile = filepath.Join(dir, "trivia_history.json")
+	utils.ScrambleWordsFile = filepath.Join(dir, "scramble_words.json")
+	utils.ScrambleHistoryFile = filepath.Join(dir, "scramble_history.json")
+
+	return func() {
+		utils.TriviaQuestionsFile, utils.TriviaHistoryFile = questions, questionHistory
+		utils.ScrambleWordsFile, utils.ScrambleHistoryFile = words, wordHistory
+	}
+}
+
+func WriteJSON(t testing.TB, path string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,49 +1,135 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/gempir/go-twitch-irc/v4"
 	"github.com/joho/godotenv"
 
 	"twitchgo/commands"
+	"twitchgo/config"
 	"twitchgo/handlers"
+	"twitchgo/utils"
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	if err := godotenv.Load(); err != nil {
+	configPath := flag.String("config", config.DefaultPath, "arquivo de configuração (YAML)")
+	printConfig := flag.Bool("print-config", false, "mostra a configuração efetiva e sai")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil && !*printConfig {
 		log.Fatal("Erro ao carregar .env")
 	}
 
+	cfg, err := loadConfig(*configPath, flagSet("config"))
+	if err != nil {
+		log.Fatalf("Erro na configuração: %v", err)
+	}
+
 	nick := os.Getenv("TWITCH_NICK")
 	oauth := os.Getenv("TWITCH_OAUTH")
-	channel := os.Getenv("TWITCH_CHANNEL")
-	prefix := os.Getenv("PREFIX")
+	legacyChannel := os.Getenv("TWITCH_CHANNEL")
+
+	applyEnvGlobals(cfg, legacyChannel)
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Erro na configuração:\n%v", err)
+	}
+	channelNames := cfg.Join
 
-	if nick == "" || oauth == "" || channel == "" {
+	defaults, err := cfg.Defaults(commands.DefaultChannelConfig())
+	if err != nil {
+		log.Fatalf("Erro na configuração:\n%v", err)
+	}
+	applyEnvDefaults(&defaults)
+
+	// Each layer overrides the one before: built-in defaults, the file's
+	// defaults, environment variables, the file's section for the channel
+	// and the channel's own environment variables.
+	channelConfig := func(name string) (commands.ChannelConfig, error) {
+		config, err := cfg.Channel(name, defaults)
+		if err != nil {
+			return config, err
+		}
+		applyEnvChannel(&config)
+		return config, nil
+	}
+
+	if *printConfig {
+		var configs []commands.ChannelConfig
+		for _, name := range uniqueChannels(append(channelNames, cfg.Overridden()...)) {
+			config, err := channelConfig(name)
+			if err != nil {
+				log.Fatalf("Erro na configuração:\n%v", err)
+			}
+			configs = append(configs, config)
+		}
+		if err := cfg.Print(os.Stdout, defaults, configs); err != nil {
+			log.Fatalf("Erro ao mostrar a configuração: %v", err)
+		}
+		return
+	}
+
+	if nick == "" || oauth == "" {
 		log.Fatal("Variáveis de ambiente estão faltando")
 	}
+	if len(channelNames) == 0 {
+		log.Fatal("Nenhum canal configurado: use join no arquivo de configuração ou TWITCH_CHANNELS")
+	}
+
+	commands.ConfigurePermissions(cfg.Permissions)
+
+	commands.Setup(defaults)
+	commands.ConfigureChannels(channelConfig)
+
+	for _, name := range channelNames {
+		config, err := channelConfig(name)
+		if err != nil {
+			log.Fatalf("Erro na configuração:\n%v", err)
+		}
+
+		// Single-channel installs kept their economy directly under data/.
+		if commands.NormalizeChannel(name) == commands.NormalizeChannel(legacyChannel) {
+			if err := utils.MigrateLegacyPointsData(defaults.Points, config.Points); err != nil {
+				log.Fatalf("Erro ao migrar dados de %s: %v", name, err)
+			}
+		}
+
+		if _, err := commands.AddChannel(config); err != nil {
+			log.Fatalf("Erro ao configurar canal %s: %v", name, err)
+		}
+	}
+
+	for _, name := range cfg.Overridden() {
+		if commands.GetChannel(name) == nil {
+			log.Printf("Configuração para %s será usada quando o canal for adicionado", name)
+		}
+	}
 
 	client := twitch.NewClient(nick, oauth)
 
 	client.OnConnect(func() {
-		log.Printf("✅ Conectado como %s ao canal %s", nick, channel)
+		log.Printf("✅ Conectado como %s aos canais %s", nick, strings.Join(commands.ChannelNames(), ", "))
 	})
 
-	client.OnPrivateMessage(func(msg twitch.PrivateMessage) {
-		handlers.OnMessage(client, msg, prefix)
-	})
+	handlers.Attach(client)
 
-	client.Join(channel)
+	client.Join(commands.ChannelNames()...)
 
 	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
+		ticker := time.NewTicker(cfg.SaveInterval)
 		defer ticker.Stop()
 
 		for range ticker.C {
@@ -65,9 +151,109 @@ func main() {
 	<-quit
 	log.Println("🛑 Finalizando conexão com a Twitch...")
 
-	if err := commands.SavePointsData(); err != nil {
-		log.Printf("Error saving points data on shutdown: %v", err)
+	if err := commands.ClosePointsData(); err != nil {
+		log.Printf("Error closing points data: %v", err)
 	}
 
 	client.Disconnect()
 }
+
+// loadConfig reads the config file at path. Without one the built-in
+// settings are used, unless the path was asked for explicitly.
+func loadConfig(path string, explicit bool) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		log.Printf("%s não encontrado, usando a configuração padrão", path)
+		return config.Default(), nil
+	}
+	return cfg, err
+}
+
+// applyEnvGlobals lets the environment override the file's channel list and
+// permissions. TWITCH_CHANNEL is the single-channel setting from before
+// TWITCH_CHANNELS existed.
+func applyEnvGlobals(cfg *config.Config, legacyChannel string) {
+	if names := splitList(os.Getenv("TWITCH_CHANNELS")); len(names) > 0 {
+		cfg.Join = names
+	} else if len(cfg.Join) == 0 && legacyChannel != "" {
+		cfg.Join = []string{legacyChannel}
+	}
+
+	if admins := splitList(os.Getenv("BOT_ADMINS")); len(admins) > 0 {
+		cfg.Permissions.Admins = admins
+	}
+	if message, ok := os.LookupEnv("PERMISSION_DENIED_MESSAGE"); ok {
+		cfg.Permissions.DeniedMessage = message
+	}
+}
+
+func applyEnvDefaults(defaults *commands.ChannelConfig) {
+	if prefix, ok := os.LookupEnv("PREFIX"); ok {
+		defaults.Prefix = prefix
+	}
+
+	if tz := os.Getenv("CHANNEL_TIMEZONE"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			log.Fatalf("Fuso horário inválido em CHANNEL_TIMEZONE: %v", err)
+		}
+		defaults.Daily.Location = loc
+	}
+
+	if backend := os.Getenv("POINTS_BACKEND"); backend != "" {
+		defaults.Points.Backend = backend
+	}
+	if path := os.Getenv("POINTS_SQLITE_PATH"); path != "" {
+		defaults.Points.SQLitePath = path
+	}
+	if backups := os.Getenv("POINTS_BACKUPS"); backups != "" {
+		n, err := strconv.Atoi(backups)
+		if err != nil || n < 0 {
+			log.Fatalf("Valor inválido em POINTS_BACKUPS: %q", backups)
+		}
+		defaults.Points.Backups = n
+	}
+}
+
+func applyEnvChannel(config *commands.ChannelConfig) {
+	key := strings.ToUpper(config.Name)
+	if prefix, ok := os.LookupEnv("PREFIX_" + key); ok {
+		config.Prefix = prefix
+	}
+	if enabled := splitList(os.Getenv("COMMANDS_" + key)); len(enabled) > 0 {
+		config.Commands = enabled
+	}
+}
+
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func uniqueChannels(names []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, name := range names {
+		if name = commands.NormalizeChannel(name); !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
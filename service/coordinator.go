@@ -0,0 +1,275 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"twitchgo/types"
+)
+
+// Game is a chat game the Coordinator schedules. RoundEngine provides all of
+// it.
+type Game interface {
+	CheckAnswer(sender types.Sender, message types.ChatMessage)
+	Stop()
+	Watch(f func(busy bool))
+}
+
+type CoordinatorConfig struct {
+	// MaxActive is how many games may run at once; 1 makes them exclusive
+	// and 0 lifts the limit.
+	MaxActive int `yaml:"max_active"`
+	// QueueSize is how many requests may wait for a free slot. With 0, a
+	// request made while the limit is reached is turned down.
+	QueueSize int `yaml:"queue_size"`
+	// QueueDelay is the pause between a game ending and a queued one
+	// starting.
+	QueueDelay time.Duration `yaml:"queue_delay"`
+}
+
+type CoordinatorMessageGenerator interface {
+	FormatQueued(user, game string, position int) string
+	FormatAlreadyQueued(user, game string) string
+	FormatBusy(user string, active []string) string
+	FormatStatus(active, queued []string) string
+	FormatQueueCleared(count int) string
+}
+
+type defaultCoordinatorMessageGenerator struct{}
+
+func (g *defaultCoordinatorMessageGenerator) FormatQueued(user, game string, position int) string {
+	return fmt.Sprintf("[Jogos] @%s %s entrou na fila (posição %d).", user, game, position)
+}
+
+func (g *defaultCoordinatorMessageGenerator) FormatAlreadyQueued(user, game string) string {
+	return fmt.Sprintf("[Jogos] @%s %s já está na fila.", user, game)
+}
+
+func (g *defaultCoordinatorMessageGenerator) FormatBusy(user string, active []string) string {
+	return fmt.Sprintf("[Jogos] @%s Espere terminar: %s.", user, strings.Join(active, ", "))
+}
+
+func (g *defaultCoordinatorMessageGenerator) FormatStatus(active, queued []string) string {
+	status := "nenhum jogo em andamento"
+	if len(active) > 0 {
+		status = "em andamento: " + strings.Join(active, ", ")
+	}
+	if len(queued) > 0 {
+		status += " | fila: " + strings.Join(queued, ", ")
+	}
+	return "[Jogos] " + status
+}
+
+func (g *defaultCoordinatorMessageGenerator) FormatQueueCleared(count int) string {
+	return fmt.Sprintf("[Jogos] Fila limpa (%d removidos).", count)
+}
+
+type queuedGame struct {
+	name  string
+	start func()
+}
+
+// Coordinator keeps a channel's games from overlapping beyond its
+// CoordinatorConfig, queues the requests that have to wait and hands chat
+// messages only to the games that are running.
+type Coordinator struct {
+	mutex  sync.Mutex
+	config CoordinatorConfig
+	clock  types.Clock
+	names  []string
+	games  map[string]Game
+	active map[string]bool
+	// starting holds games whose start function is running, so they count
+	// towards the limit before they report in.
+	starting   map[string]bool
+	queue      []queuedGame
+	queueTimer types.Timer
+	messageGen CoordinatorMessageGenerator
+}
+
+func NewCoordinator(config CoordinatorConfig, clock types.Clock) *Coordinator {
+	return &Coordinator{
+		config:     config,
+		clock:      clock,
+		games:      make(map[string]Game),
+		active:     make(map[string]bool),
+		starting:   make(map[string]bool),
+		messageGen: &defaultCoordinatorMessageGenerator{},
+	}
+}
+
+// Register adds game under name, which is also how chat sees it.
+func (c *Coordinator) Register(name string, game Game) {
+	c.mutex.Lock()
+	c.names = append(c.names, name)
+	c.games[name] = game
+	c.mutex.Unlock()
+
+	game.Watch(func(busy bool) { c.setActive(name, busy) })
+}
+
+// Request runs start, which should start game name, as soon as the policy
+// allows. A game that is already running gets the request right away so it
+// can answer it itself.
+func (c *Coordinator) Request(sender types.Sender, message types.ChatMessage, name string, start func()) {
+	c.mutex.Lock()
+
+	if c.active[name] || c.starting[name] || c.hasRoom() {
+		c.starting[name] = true
+		c.mutex.Unlock()
+		c.run(name, start)
+		return
+	}
+	defer c.mutex.Unlock()
+
+	for _, queued := range c.queue {
+		if queued.name == name {
+			sender.Say(message.Channel, c.messageGen.FormatAlreadyQueued(message.User.DisplayName, name))
+			return
+		}
+	}
+
+	if len(c.queue) >= c.config.QueueSize {
+		sender.Say(message.Channel, c.messageGen.FormatBusy(message.User.DisplayName, c.activeNames()))
+		return
+	}
+
+	c.queue = append(c.queue, queuedGame{name: name, start: start})
+	sender.Say(message.Channel, c.messageGen.FormatQueued(message.User.DisplayName, name, len(c.queue)))
+	log.Printf("[Games] %s queued by %s", name, message.User.Name)
+}
+
+// CheckAnswer passes message to every game that is running.
+func (c *Coordinator) CheckAnswer(sender types.Sender, message types.ChatMessage) {
+	c.mutex.Lock()
+	var games []Game
+	for _, name := range c.names {
+		if c.active[name] {
+			games = append(games, c.games[name])
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, game := range games {
+		game.CheckAnswer(sender, message)
+	}
+}
+
+// Status describes the running and queued games.
+func (c *Coordinator) Status() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	queued := make([]string, len(c.queue))
+	for i, entry := range c.queue {
+		queued[i] = entry.name
+	}
+	return c.messageGen.FormatStatus(c.activeNames(), queued)
+}
+
+// ClearQueue drops every waiting request and reports how many there were.
+func (c *Coordinator) ClearQueue() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	count := len(c.queue)
+	c.queue = nil
+	c.stopQueueTimer()
+	return c.messageGen.FormatQueueCleared(count)
+}
+
+// Stop clears the queue and stops every game without announcing it.
+func (c *Coordinator) Stop() {
+	c.mutex.Lock()
+	c.queue = nil
+	c.stopQueueTimer()
+	games := make([]Game, 0, len(c.names))
+	for _, name := range c.names {
+		games = append(games, c.games[name])
+	}
+	c.mutex.Unlock()
+
+	for _, game := range games {
+		game.Stop()
+	}
+}
+
+// setActive is the games' Watch callback. It runs with the game locked, so
+// queued games are started later from a timer rather than from here.
+func (c *Coordinator) setActive(name string, busy bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.active[name] = busy
+	if !busy && len(c.queue) > 0 && c.queueTimer == nil {
+		c.queueTimer = c.clock.AfterFunc(c.config.QueueDelay, c.startQueued)
+	}
+}
+
+func (c *Coordinator) startQueued() {
+	c.mutex.Lock()
+	c.queueTimer = nil
+
+	var next []queuedGame
+	for len(c.queue) > 0 && c.hasRoom() {
+		entry := c.queue[0]
+		c.queue = c.queue[1:]
+		c.starting[entry.name] = true
+		next = append(next, entry)
+	}
+	c.mutex.Unlock()
+
+	for _, entry := range next {
+		log.Printf("[Games] Starting queued %s", entry.name)
+		c.run(entry.name, entry.start)
+	}
+}
+
+// run calls start, keeping name's slot reserved until it returns. If the game
+// didn't start after all, the next queued one gets its turn.
+func (c *Coordinator) run(name string, start func()) {
+	start()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.starting, name)
+	if !c.active[name] && len(c.queue) > 0 && c.hasRoom() && c.queueTimer == nil {
+		c.queueTimer = c.clock.AfterFunc(c.config.QueueDelay, c.startQueued)
+	}
+}
+
+// hasRoom reports whether another game may start; callers hold the mutex.
+func (c *Coordinator) hasRoom() bool {
+	if c.config.MaxActive <= 0 {
+		return true
+	}
+
+	running := len(c.starting)
+	for name, busy := range c.active {
+		if busy && !c.starting[name] {
+			running++
+		}
+	}
+	return running < c.config.MaxActive
+}
+
+func (c *Coordinator) activeNames() []string {
+	var names []string
+	for _, name := range c.names {
+		if c.active[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Coordinator) stopQueueTimer() {
+	if c.queueTimer != nil {
+		c.queueTimer.Stop()
+		c.queueTimer = nil
+	}
+}
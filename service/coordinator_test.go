@@ -0,0 +1,248 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"twitchgo/internal/chattest"
+	"twitchgo/internal/clocktest"
+	"twitchgo/internal/testenv"
+	"twitchgo/types"
+)
+
+// fakeGame runs until finish or Stop is called, reporting to the
+// coordinator the way RoundEngine does.
+type fakeGame struct {
+	watch   func(busy bool)
+	running bool
+	starts  int
+	answers int
+}
+
+func (g *fakeGame) CheckAnswer(sender types.Sender, message types.ChatMessage) { g.answers++ }
+func (g *fakeGame) Watch(f func(busy bool))                                    { g.watch = f }
+func (g *fakeGame) Stop()                                                      { g.finish() }
+
+func (g *fakeGame) start() {
+	g.starts++
+	g.running = true
+	g.watch(true)
+}
+
+func (g *fakeGame) finish() {
+	if g.running {
+		g.running = false
+		g.watch(false)
+	}
+}
+
+type coordinatorFixture struct {
+	coordinator *Coordinator
+	games       map[string]*fakeGame
+	chat        *chattest.Recorder
+	clock       *clocktest.Manual
+}
+
+func newCoordinatorFixture(t *testing.T, config CoordinatorConfig, names ...string) *coordinatorFixture {
+	t.Helper()
+
+	env := testenv.New(t)
+	f := &coordinatorFixture{
+		coordinator: NewCoordinator(config, env.Clock),
+		games:       make(map[string]*fakeGame),
+		chat:        env.Chat,
+		clock:       env.Clock,
+	}
+	for _, name := range names {
+		game := &fakeGame{}
+		f.games[name] = game
+		f.coordinator.Register(name, game)
+	}
+	return f
+}
+
+// request asks for game name as user and returns what the coordinator said.
+func (f *coordinatorFixture) request(user, name string) []string {
+	before := len(f.chat.Texts(testChannel))
+	f.coordinator.Request(f.chat, f.chat.Message(testChannel, user, "#"+name), name, f.games[name].start)
+	return f.chat.Texts(testChannel)[before:]
+}
+
+func (f *coordinatorFixture) running() string {
+	var names []string
+	for _, name := range f.coordinator.names {
+		if f.games[name].running {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func TestCoordinatorMaxActive(t *testing.T) {
+	tests := []struct {
+		maxActive int
+		running   string
+		queued    int
+	}{
+		{1, "a", 2},
+		{2, "a,b", 1},
+		{0, "a,b,c", 0},
+	}
+
+	for _, tt := range tests {
+		f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: tt.maxActive, QueueSize: 5}, "a", "b", "c")
+		for _, name := range []string{"a", "b", "c"} {
+			f.request("viewer", name)
+		}
+
+		if running := f.running(); running != tt.running {
+			t.Errorf("MaxActive %d: running %q, want %q", tt.maxActive, running, tt.running)
+		}
+		if queued := len(f.coordinator.queue); queued != tt.queued {
+			t.Errorf("MaxActive %d: %d queued, want %d", tt.maxActive, queued, tt.queued)
+		}
+	}
+}
+
+func TestCoordinatorRunningGameGetsItsOwnRequest(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 5}, "a", "b")
+
+	f.request("viewer", "a")
+	// A request for the game already running goes to the game itself.
+	if said := f.request("viewer", "a"); len(said) != 0 || f.games["a"].starts != 2 {
+		t.Fatalf("second request for a: said %q, started %d times", said, f.games["a"].starts)
+	}
+}
+
+func TestCoordinatorQueueSize(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 2}, "a", "b", "c", "d")
+
+	f.request("viewer", "a")
+	tests := []struct {
+		game string
+		want string
+	}{
+		{"b", "[Jogos] @viewer b entrou na fila (posição 1)."},
+		{"b", "b já"},
+		{"c", "c entrou na fila (posição 2)."},
+		{"d", "[Jogos] @viewer Espere terminar: a."},
+	}
+	for _, tt := range tests {
+		if said := f.request("viewer", tt.game); len(said) != 1 || !strings.Contains(said[0], tt.want) {
+			t.Errorf("request %s: said %q, want %q", tt.game, said, tt.want)
+		}
+	}
+
+	if status := f.coordinator.Status(); status != "[Jogos] em andamento: a | fila: b, c" {
+		t.Errorf("Status() = %q", status)
+	}
+	if f.games["d"].starts != 0 {
+		t.Error("request turned down by a full queue still started")
+	}
+}
+
+func TestCoordinatorQueueSizeZero(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1}, "a", "b")
+
+	f.request("viewer", "a")
+	if said := f.request("viewer", "b"); len(said) != 1 || !strings.Contains(said[0], "Espere terminar: a") {
+		t.Fatalf("request with no queue: said %q", said)
+	}
+}
+
+func TestCoordinatorQueueDelay(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 5, QueueDelay: 5 * time.Second}, "a", "b", "c")
+
+	f.request("viewer", "a")
+	f.request("viewer", "b")
+	f.request("viewer", "c")
+
+	f.games["a"].finish()
+	f.clock.Advance(5*time.Second - time.Millisecond)
+	if running := f.running(); running != "" {
+		t.Fatalf("running %q before the queue delay passed", running)
+	}
+	f.clock.Advance(time.Millisecond)
+	if running := f.running(); running != "b" {
+		t.Fatalf("running %q after the queue delay, want b", running)
+	}
+
+	// Queued games start in order, one per free slot.
+	f.games["b"].finish()
+	f.clock.Advance(5 * time.Second)
+	if running := f.running(); running != "c" {
+		t.Fatalf("running %q, want c", running)
+	}
+	if pending := f.clock.Pending(); pending != 0 {
+		t.Fatalf("%d timers left with the queue empty", pending)
+	}
+}
+
+func TestCoordinatorAnswersGoToRunningGames(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 5}, "a", "b")
+
+	f.request("viewer", "a")
+	f.request("viewer", "b")
+	f.coordinator.CheckAnswer(f.chat, f.chat.Message(testChannel, "viewer", "resposta"))
+
+	if f.games["a"].answers != 1 || f.games["b"].answers != 0 {
+		t.Fatalf("answers: a got %d, queued b got %d; want 1 and 0", f.games["a"].answers, f.games["b"].answers)
+	}
+}
+
+func TestCoordinatorClearQueue(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 5, QueueDelay: 5 * time.Second}, "a", "b", "c")
+
+	f.request("viewer", "a")
+	f.request("viewer", "b")
+	f.request("viewer", "c")
+	if said := f.coordinator.ClearQueue(); said != "[Jogos] Fila limpa (2 removidos)." {
+		t.Fatalf("ClearQueue() = %q", said)
+	}
+
+	f.games["a"].finish()
+	f.clock.Advance(time.Minute)
+	if running := f.running(); running != "" || f.games["b"].starts+f.games["c"].starts != 0 {
+		t.Fatalf("cleared requests started: running %q", running)
+	}
+}
+
+// TestCoordinatorCancelDuringDelay clears the queue after a game ended but
+// before the queued one was due.
+func TestCoordinatorCancelDuringDelay(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 5, QueueDelay: 5 * time.Second}, "a", "b")
+
+	f.request("viewer", "a")
+	f.request("viewer", "b")
+	f.games["a"].finish()
+	f.clock.Advance(2 * time.Second)
+
+	f.coordinator.ClearQueue()
+	if pending := f.clock.Pending(); pending != 0 {
+		t.Fatalf("%d timers left after clearing the queue", pending)
+	}
+	f.clock.Advance(time.Minute)
+	if f.games["b"].starts != 0 {
+		t.Fatal("queued game started after the queue was cleared")
+	}
+}
+
+func TestCoordinatorStop(t *testing.T) {
+	f := newCoordinatorFixture(t, CoordinatorConfig{MaxActive: 1, QueueSize: 5, QueueDelay: 5 * time.Second}, "a", "b")
+
+	f.request("viewer", "a")
+	f.request("viewer", "b")
+	f.coordinator.Stop()
+
+	if running := f.running(); running != "" {
+		t.Fatalf("running %q after Stop", running)
+	}
+	f.clock.Advance(time.Minute)
+	if f.games["b"].starts != 0 {
+		t.Fatal("queued game started after Stop")
+	}
+	if status := f.coordinator.Status(); status != "[Jogos] nenhum jogo em andamento" {
+		t.Fatalf("Status() after Stop = %q", status)
+	}
+}
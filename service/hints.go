@@ -0,0 +1,23 @@
+package service
+
+// HintStage reveals the share Reveal of the answer's letters once the share
+// At of the time limit has passed.
+type HintStage struct {
+	At     float64 `yaml:"at"`
+	Reveal float64 `yaml:"reveal"`
+}
+
+type HintConfig struct {
+	Stages []HintStage `yaml:"stages"`
+	// Penalty is the share of the reward lost with each hint given.
+	Penalty float64 `yaml:"penalty"`
+}
+
+// RewardFactor is what is left of the reward after hints hints.
+func (c HintConfig) RewardFactor(hints int) float64 {
+	factor := 1 - c.Penalty*float64(hints)
+	if factor < 0 {
+		return 0
+	}
+	return factor
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"twitchgo/types"
+)
+
+type RewardConfig struct {
+	BasePoints      int     `yaml:"base_points"`
+	BonusPoints     int     `yaml:"bonus_points"`
+	BonusSimilarity float64 `yaml:"bonus_similarity"`
+}
+
+func (c RewardConfig) PointsFor(similarity float64) int {
+	if similarity >= c.BonusSimilarity {
+		return c.BonusPoints
+	}
+	return c.BasePoints
+}
+
+// Scaled multiplies both point amounts by factor, rounding to the nearest
+// point.
+func (c RewardConfig) Scaled(factor float64) RewardConfig {
+	c.BasePoints = int(math.Round(float64(c.BasePoints) * factor))
+	c.BonusPoints = int(math.Round(float64(c.BonusPoints) * factor))
+	return c
+}
+
+// DifficultyScale stretches the reward and the time limits of a round played
+// at some difficulty.
+type DifficultyScale struct {
+	Reward float64 `yaml:"reward"`
+	Time   float64 `yaml:"time"`
+}
+
+// ScaleFor returns the scale configured for d, or no scaling at all.
+func ScaleFor(scales map[types.Difficulty]DifficultyScale, d types.Difficulty) DifficultyScale {
+	if scale, ok := scales[d.Level()]; ok {
+		return scale
+	}
+	return DifficultyScale{Reward: 1, Time: 1}
+}
+
+func (s DifficultyScale) Duration(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * s.Time)
+}
+
+type Payout struct {
+	Username string
+	Amount   int
+	Reason   types.Reason
+}
+
+type RewardsService struct {
+	database types.PointsDatabase
+}
+
+func NewRewardsService(database types.PointsDatabase) *RewardsService {
+	return &RewardsService{
+		database: database,
+	}
+}
+
+func (rs *RewardsService) Pay(payout Payout) error {
+	if payout.Amount <= 0 {
+		return nil
+	}
+
+	if err := rs.database.AddPoints(payout.Username, payout.Amount, payout.Reason); err != nil {
+		return fmt.Errorf("failed to pay %d points to %s (%s): %w", payout.Amount, payout.Username, payout.Reason, err)
+	}
+
+	log.Printf("[Rewards] Paid %d points to %s (reason: %s)", payout.Amount, payout.Username, payout.Reason)
+	return nil
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"twitchgo/types"
+	"twitchgo/utils"
+)
+
+// RoundConfig is the part of a guessing game's config that the RoundEngine
+// runs on. Timeout and Reward apply to puzzles that don't set their own.
+type RoundConfig struct {
+	Cooldown  time.Duration     `yaml:"cooldown"`
+	Hints     HintConfig        `yaml:"hints"`
+	Timeout   time.Duration     `yaml:"timeout"`
+	MaxLength int               `yaml:"max_length"`
+	Match     utils.MatchConfig `yaml:"match"`
+	Reward    RewardConfig      `yaml:"reward"`
+}
+
+// Puzzle is what one round asks chat to guess.
+type Puzzle[T any] struct {
+	// Item is the game's own record, handed back to PuzzleSource.Check.
+	Item T
+	ID   string
+	// Prompt is announced as the round opens.
+	Prompt string
+	// Answer is hinted at during the round and revealed when it ends.
+	Answer string
+	// Clue, when set, is shown along with the first hint.
+	Clue string
+	// Reward and Timeout fall back to the config's when left zero.
+	Reward  RewardConfig
+	Timeout time.Duration
+}
+
+// PuzzleSource is all a guessing game has to provide besides its messages.
+type PuzzleSource[T any] interface {
+	// Draw picks a puzzle about topic, or about anything when topic is
+	// empty. It reports false when there is nothing to play.
+	Draw(topic string) (Puzzle[T], bool)
+	// Check judges guess against item, scoring its similarity from 0 to 1.
+	Check(guess string, item T) (bool, float64)
+}
+
+type RoundMessageGenerator interface {
+	FormatCorrectAnswer(user, answer string, points int) string
+	FormatCloseAnswer(user, guess string, similarity float64) string
+	FormatHint(hint string) string
+	FormatClueHint(hint, clue string) string
+	FormatTimeout(answer string) string
+	FormatAlreadyRunning(user string) string
+	FormatStopped() string
+	FormatNoPuzzle(topic string) string
+}
+
+type Round[T any] struct {
+	Active       bool
+	Puzzle       Puzzle[T]
+	StartTime    time.Time
+	LastStarted  time.Time
+	Hints        int // hints given so far
+	hintTimers   []types.Timer
+	timeoutTimer types.Timer
+}
+
+// RoundEngine runs a guessing game one round at a time: it draws a puzzle,
+// gives staged hints, times the round out, judges answers and pays the
+// winner.
+type RoundEngine[T any] struct {
+	// mutex guards round. Chat messages and timers both end up here, and
+	// holding it across a whole round step is what lets only the first
+	// correct answer through.
+	mutex    sync.Mutex
+	round    *Round[T]
+	name     string
+	reason   types.Reason
+	source   PuzzleSource[T]
+	messages RoundMessageGenerator
+	config   RoundConfig
+	rewards  *RewardsService
+	clock    types.Clock
+	// onRoundEnd runs with the mutex held after a round is won or times
+	// out. winner is nil on a timeout.
+	onRoundEnd func(sender types.Sender, channel string, winner *types.ChatUser, points int)
+	// betweenRounds keeps the game busy while no round is running, as a
+	// tournament does during its breaks.
+	betweenRounds func() bool
+	busy          bool
+	watch         func(busy bool)
+}
+
+// NewRoundEngine builds an engine for a game called name, whose payouts are
+// recorded under reason.
+func NewRoundEngine[T any](name string, reason types.Reason, source PuzzleSource[T], messages RoundMessageGenerator, config RoundConfig, rewards *RewardsService, clock types.Clock) *RoundEngine[T] {
+	return &RoundEngine[T]{
+		round:    &Round[T]{},
+		name:     name,
+		reason:   reason,
+		source:   source,
+		messages: messages,
+		config:   config,
+		rewards:  rewards,
+		clock:    clock,
+	}
+}
+
+// Watch registers f to be told each time the game starts or stops being
+// busy. f runs with the game locked and must not call back into it.
+func (e *RoundEngine[T]) Watch(f func(busy bool)) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.watch = f
+}
+
+// unlock reports any change in whether the game is busy, then releases the
+// mutex.
+func (e *RoundEngine[T]) unlock() {
+	busy := e.round.Active || (e.betweenRounds != nil && e.betweenRounds())
+	if busy != e.busy {
+		e.busy = busy
+		if e.watch != nil {
+			e.watch(busy)
+		}
+	}
+	e.mutex.Unlock()
+}
+
+// Start opens a round about topic unless one is running or the game is
+// cooling down.
+func (e *RoundEngine[T]) Start(sender types.Sender, message types.ChatMessage, topic string) {
+	e.mutex.Lock()
+	defer e.unlock()
+
+	e.start(sender, message, topic)
+}
+
+func (e *RoundEngine[T]) start(sender types.Sender, message types.ChatMessage, topic string) {
+	if e.round.Active {
+		if e.clock.Now().Sub(e.round.StartTime) > 5*time.Second {
+			sender.Say(message.Channel, e.messages.FormatAlreadyRunning(message.User.DisplayName))
+		}
+		return
+	}
+
+	if e.clock.Now().Sub(e.round.LastStarted) < e.config.Cooldown {
+		log.Printf("[%s] Command blocked -- in silent cooldown.", e.name)
+		return
+	}
+
+	e.open(sender, message.Channel, topic)
+}
+
+// open draws a puzzle and starts a round with it, reporting false when there
+// was nothing to play; callers hold the mutex.
+func (e *RoundEngine[T]) open(sender types.Sender, channel string, topic string) bool {
+	puzzle, ok := e.source.Draw(topic)
+	if !ok {
+		sender.Say(channel, e.messages.FormatNoPuzzle(topic))
+		return false
+	}
+
+	if puzzle.Reward == (RewardConfig{}) {
+		puzzle.Reward = e.config.Reward
+	}
+	if puzzle.Timeout == 0 {
+		puzzle.Timeout = e.config.Timeout
+	}
+
+	now := e.clock.Now()
+	e.round = &Round[T]{
+		Active:      true,
+		Puzzle:      puzzle,
+		StartTime:   now,
+		LastStarted: now,
+	}
+
+	sender.Say(channel, puzzle.Prompt)
+	log.Printf("[%s] Puzzle %s: %s", e.name, puzzle.ID, puzzle.Answer)
+
+	e.schedule(e.round, sender, channel)
+	return true
+}
+
+// StopRound ends the running round and says so.
+func (e *RoundEngine[T]) StopRound(sender types.Sender, message types.ChatMessage) {
+	e.mutex.Lock()
+	defer e.unlock()
+
+	if e.round.Active {
+		e.stopRound()
+		sender.Say(message.Channel, e.messages.FormatStopped())
+		log.Printf("[%s] Stopped by moderator", e.name)
+	}
+}
+
+// Stop ends the running round without announcing it.
+func (e *RoundEngine[T]) Stop() {
+	e.mutex.Lock()
+	defer e.unlock()
+
+	if e.round.Active {
+		e.stopRound()
+	}
+}
+
+func (e *RoundEngine[T]) CheckAnswer(sender types.Sender, message types.ChatMessage) {
+	e.mutex.Lock()
+	defer e.unlock()
+
+	if !e.round.Active || len(message.Text) > e.config.MaxLength {
+		return
+	}
+
+	correct, similarity := e.source.Check(message.Text, e.round.Puzzle.Item)
+
+	if correct {
+		e.handleCorrectAnswer(sender, message, similarity)
+	} else if similarity >= e.config.Match.Close && len(message.Text) < e.config.MaxLength {
+		e.handleCloseAnswer(sender, message, similarity)
+	}
+}
+
+func (e *RoundEngine[T]) IsActive() bool {
+	e.mutex.Lock()
+	defer e.unlock()
+
+	return e.round.Active
+}
+
+// Current returns the item being played, or nil between rounds.
+func (e *RoundEngine[T]) Current() *T {
+	e.mutex.Lock()
+	defer e.unlock()
+
+	if e.round.Active {
+		current := e.round.Puzzle.Item
+		return &current
+	}
+	return nil
+}
+
+// schedule arms the hint and timeout timers for round; callers hold the
+// mutex. A timer that fires after round has ended, or been replaced by a new
+// one, does nothing.
+func (e *RoundEngine[T]) schedule(round *Round[T], sender types.Sender, channel string) {
+	for i, stage := range e.config.Hints.Stages {
+		at := time.Duration(float64(round.Puzzle.Timeout) * stage.At)
+		round.hintTimers = append(round.hintTimers, e.clock.AfterFunc(at, func() {
+			e.mutex.Lock()
+			defer e.unlock()
+
+			if e.round == round && round.Active && round.Hints <= i {
+				e.giveHint(sender, channel, i, stage)
+			}
+		}))
+	}
+	round.timeoutTimer = e.clock.AfterFunc(round.Puzzle.Timeout, func() {
+		e.mutex.Lock()
+		defer e.unlock()
+
+		if e.round == round && round.Active {
+			e.handleTimeout(sender, channel)
+		}
+	})
+}
+
+func (e *RoundEngine[T]) giveHint(sender types.Sender, channel string, index int, stage HintStage) {
+	e.round.Hints = index + 1
+	hint := utils.GenerateHint(e.round.Puzzle.Answer, stage.Reveal)
+	if index == 0 && e.round.Puzzle.Clue != "" {
+		sender.Say(channel, e.messages.FormatClueHint(hint, e.round.Puzzle.Clue))
+		return
+	}
+	sender.Say(channel, e.messages.FormatHint(hint))
+}
+
+func (e *RoundEngine[T]) handleTimeout(sender types.Sender, channel string) {
+	e.stopRound()
+	sender.Say(channel, e.messages.FormatTimeout(e.round.Puzzle.Answer))
+	log.Printf("[%s] Timeout - Answer was: %s", e.name, e.round.Puzzle.Answer)
+
+	if e.onRoundEnd != nil {
+		e.onRoundEnd(sender, channel, nil, 0)
+	}
+}
+
+func (e *RoundEngine[T]) handleCorrectAnswer(sender types.Sender, message types.ChatMessage, similarity float64) {
+	e.stopRound()
+
+	reward := e.round.Puzzle.Reward.Scaled(e.config.Hints.RewardFactor(e.round.Hints))
+	points := reward.PointsFor(similarity)
+	if err := e.rewards.Pay(Payout{Username: message.User.Name, Amount: points, Reason: e.reason}); err != nil {
+		log.Printf("[%s] %v", e.name, err)
+	}
+
+	sender.Say(message.Channel, e.messages.FormatCorrectAnswer(
+		message.User.DisplayName, e.round.Puzzle.Answer, points))
+
+	log.Printf("[%s] %s answered correctly with similarity %.2f",
+		e.name, message.User.DisplayName, similarity)
+
+	if e.onRoundEnd != nil {
+		e.onRoundEnd(sender, message.Channel, &message.User, points)
+	}
+}
+
+func (e *RoundEngine[T]) handleCloseAnswer(sender types.Sender, message types.ChatMessage, similarity float64) {
+	sender.Say(message.Channel, e.messages.FormatCloseAnswer(
+		message.User.DisplayName, message.Text, similarity))
+	log.Printf("[%s] %s is close (%.0f%%)", e.name, message.User.DisplayName, similarity*100)
+}
+
+func (e *RoundEngine[T]) stopRound() {
+	for _, timer := range e.round.hintTimers {
+		timer.Stop()
+	}
+	if e.round.timeoutTimer != nil {
+		e.round.timeoutTimer.Stop()
+	}
+	e.round.Active = false
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"twitchgo/internal/chattest"
+	"twitchgo/internal/clocktest"
+	"twitchgo/internal/testenv"
+	"twitchgo/types"
+	"twitchgo/utils"
+)
+
+const testChannel = "canal"
+
+// wordSource always draws the same word and judges guesses by exact match.
+type wordSource struct {
+	word string
+}
+
+func (s wordSource) Draw(topic string) (Puzzle[string], bool) {
+	return Puzzle[string]{
+		Item:   s.word,
+		ID:     "w1",
+		Prompt: "[Embaralha] " + s.word,
+		Answer: s.word,
+	}, true
+}
+
+func (s wordSource) Check(guess, item string) (bool, float64) {
+	if strings.EqualFold(guess, item) {
+		return true, 1
+	}
+	return false, 0
+}
+
+type roundFixture struct {
+	engine *RoundEngine[string]
+	chat   *chattest.Recorder
+	points types.PointsDatabase
+	clock  *clocktest.Manual
+}
+
+func newRoundFixture(t *testing.T, config RoundConfig) *roundFixture {
+	t.Helper()
+
+	env := testenv.New(t)
+	points := env.Points(t)
+	engine := NewRoundEngine[string]("Test", types.ReasonScramble, wordSource{word: "gaules"},
+		&defaultScrambleMessageGenerator{}, config, NewRewardsService(points), env.Clock)
+
+	return &roundFixture{engine: engine, chat: env.Chat, points: points, clock: env.Clock}
+}
+
+func testRoundConfig() RoundConfig {
+	return RoundConfig{
+		Timeout:   30 * time.Second,
+		MaxLength: 100,
+		Match:     utils.MatchConfig{Accept: 1, Close: 0.75},
+		Reward:    RewardConfig{BasePoints: 5, BonusPoints: 10, BonusSimilarity: 0.95},
+	}
+}
+
+func (f *roundFixture) start(t *testing.T) {
+	t.Helper()
+	f.engine.Start(f.chat, f.chat.Message(testChannel, "mod", "#embaralha"), "")
+	if !f.engine.IsActive() {
+		t.Fatal("round did not start")
+	}
+}
+
+func (f *roundFixture) count(substring string) int {
+	count := 0
+	for _, text := range f.chat.Texts(testChannel) {
+		if strings.Contains(text, substring) {
+			count++
+		}
+	}
+	return count
+}
+
+// TestRoundFirstCorrectAnswerWins races many correct answers against one
+// round; run it with -race. Only one of them may be paid.
+func TestRoundFirstCorrectAnswerWins(t *testing.T) {
+	const guessers = 32
+
+	for attempt := 0; attempt < 20; attempt++ {
+		f := newRoundFixture(t, testRoundConfig())
+		f.start(t)
+
+		var wg sync.WaitGroup
+		ready := make(chan struct{})
+		for i := 0; i < guessers; i++ {
+			message := f.chat.Message(testChannel, fmt.Sprintf("viewer%d", i), "gaules")
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-ready
+				f.engine.CheckAnswer(f.chat, message)
+			}()
+		}
+		close(ready)
+		wg.Wait()
+
+		if n := f.count("acertou"); n != 1 {
+			t.Fatalf("attempt %d: %d winner announcements, want 1", attempt, n)
+		}
+
+		winners, paid := 0, 0
+		for i := 0; i < guessers; i++ {
+			if points := f.points.GetPoints(fmt.Sprintf("viewer%d", i)); points > 0 {
+				winners++
+				paid += points
+			}
+		}
+		if winners != 1 || paid != 10 {
+			t.Fatalf("attempt %d: %d winners paid %d points, want 1 paid 10", attempt, winners, paid)
+		}
+		if f.engine.IsActive() {
+			t.Fatalf("attempt %d: round still active after a correct answer", attempt)
+		}
+	}
+}
+
+// TestRoundAnswerRacesTimeout fires the timeout while answers come in. The
+// round ends exactly once, either won or timed out.
+func TestRoundAnswerRacesTimeout(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		f := newRoundFixture(t, testRoundConfig())
+		f.start(t)
+
+		var wg sync.WaitGroup
+		ready := make(chan struct{})
+		for i := 0; i < 8; i++ {
+			message := f.chat.Message(testChannel, fmt.Sprintf("viewer%d", i), "gaules")
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-ready
+				f.engine.CheckAnswer(f.chat, message)
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-ready
+			f.clock.Advance(30 * time.Second)
+		}()
+		close(ready)
+		wg.Wait()
+
+		won, timedOut := f.count("acertou"), f.count("Tempo esgotado")
+		if won+timedOut != 1 {
+			t.Fatalf("attempt %d: round ended %d times by answer and %d by timeout", attempt, won, timedOut)
+		}
+	}
+}
+
+func hintedRoundConfig() RoundConfig {
+	config := testRoundConfig()
+	config.Hints = HintConfig{
+		Stages:  []HintStage{{At: 0.25, Reveal: 0.2}, {At: 0.5, Reveal: 0.4}},
+		Penalty: 0.25,
+	}
+	return config
+}
+
+// TestRoundHintStages walks the manual clock through a 30s round with hints
+// at 7.5s and 15s.
+func TestRoundHintStages(t *testing.T) {
+	f := newRoundFixture(t, hintedRoundConfig())
+	f.start(t)
+
+	steps := []struct {
+		advance time.Duration
+		hints   int
+		timeout int
+	}{
+		{7*time.Second + 499*time.Millisecond, 0, 0},
+		{time.Millisecond, 1, 0},
+		{7*time.Second + 499*time.Millisecond, 1, 0},
+		{time.Millisecond, 2, 0},
+		{14*time.Second + 999*time.Millisecond, 2, 0},
+		{time.Millisecond, 2, 1},
+		// Nothing fires after the round is over.
+		{time.Minute, 2, 1},
+	}
+
+	for i, step := range steps {
+		f.clock.Advance(step.advance)
+		if hints := f.count("Dica:"); hints != step.hints {
+			t.Fatalf("step %d: %d hints, want %d", i, hints, step.hints)
+		}
+		if timeouts := f.count("Tempo esgotado"); timeouts != step.timeout {
+			t.Fatalf("step %d: %d timeouts, want %d", i, timeouts, step.timeout)
+		}
+	}
+
+	if f.engine.IsActive() {
+		t.Fatal("round still active after the timeout")
+	}
+	if pending := f.clock.Pending(); pending != 0 {
+		t.Fatalf("%d timers left after the round", pending)
+	}
+}
+
+// TestRoundHintsShrinkReward pays the winner less for each hint given.
+func TestRoundHintsShrinkReward(t *testing.T) {
+	tests := []struct {
+		wait   time.Duration
+		points int
+	}{
+		{time.Second, 10},
+		{8 * time.Second, 8},
+		{16 * time.Second, 5},
+	}
+
+	for _, tt := range tests {
+		f := newRoundFixture(t, hintedRoundConfig())
+		f.start(t)
+
+		f.clock.Advance(tt.wait)
+		f.engine.CheckAnswer(f.chat, f.chat.Message(testChannel, "viewer", "gaules"))
+
+		if points := f.points.GetPoints("viewer"); points != tt.points {
+			t.Errorf("answered after %s: %d points, want %d", tt.wait, points, tt.points)
+		}
+		if pending := f.clock.Pending(); pending != 0 {
+			t.Errorf("answered after %s: %d timers left", tt.wait, pending)
+		}
+	}
+}
+
+// TestRoundStopCancelsTimers stops a round between hints; the timers it
+// leaves behind must not fire into the next round.
+func TestRoundStopCancelsTimers(t *testing.T) {
+	f := newRoundFixture(t, hintedRoundConfig())
+	f.start(t)
+
+	f.clock.Advance(10 * time.Second)
+	f.engine.StopRound(f.chat, f.chat.Message(testChannel, "mod", "#embaralha parar"))
+	if f.count("Dica:") != 1 {
+		t.Fatalf("want one hint before stopping, got %q", f.chat.Texts(testChannel))
+	}
+
+	f.clock.Advance(time.Minute)
+	if hints, timeouts := f.count("Dica:"), f.count("Tempo esgotado"); hints != 1 || timeouts != 0 {
+		t.Fatalf("after stopping: %d hints and %d timeouts, want 1 and 0", hints, timeouts)
+	}
+
+	// Cooldown is zero, so a new round opens right away with fresh timers.
+	f.start(t)
+	f.clock.Advance(30 * time.Second)
+	if hints, timeouts := f.count("Dica:"), f.count("Tempo esgotado"); hints != 3 || timeouts != 1 {
+		t.Fatalf("second round: %d hints and %d timeouts, want 3 and 1", hints, timeouts)
+	}
+}
+
+func TestRoundCooldown(t *testing.T) {
+	config := testRoundConfig()
+	config.Cooldown = 10 * time.Second
+	f := newRoundFixture(t, config)
+
+	f.start(t)
+	f.engine.CheckAnswer(f.chat, f.chat.Message(testChannel, "viewer", "gaules"))
+
+	f.clock.Advance(9 * time.Second)
+	f.engine.Start(f.chat, f.chat.Message(testChannel, "viewer", "#embaralha"), "")
+	if f.engine.IsActive() {
+		t.Fatal("round started during the cooldown")
+	}
+
+	f.clock.Advance(time.Second)
+	f.start(t)
+}
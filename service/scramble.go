@@ -1,51 +1,52 @@
 package service
 
 import (
-	"context"
 	"fmt"
 	"log"
+	"math/rand"
 	"time"
 
 	"twitchgo/types"
 	"twitchgo/utils"
-
-	"github.com/gempir/go-twitch-irc/v4"
 )
 
-type ScrambleGame struct {
-	Active        bool
-	Word          types.ScrambleWord
-	ScrambledWord string
-	StartTime     time.Time
-	HintGiven     bool
-	LastStarted   time.Time
-	ctx           context.Context
-	cancel        context.CancelFunc
-}
-
+// ScrambleManager is the word scramble game on the RoundEngine.
 type ScrambleManager struct {
-	game       *ScrambleGame
+	*RoundEngine[types.ScrambleWord]
 	database   types.ScrambleDatabase
 	config     ScrambleConfig
 	messageGen ScrambleMessageGenerator
+	rng        *rand.Rand
 }
 
+const maxScrambleDraws = 5
+
 type ScrambleConfig struct {
-	Cooldown  time.Duration
-	HintTime  time.Duration
-	Timeout   time.Duration
-	MaxLength int
+	RoundConfig `yaml:",inline"`
+	// Difficulty scales Reward and Timeout per word, and Shuffle sets how
+	// each difficulty scrambles it.
+	Difficulty map[types.Difficulty]DifficultyScale       `yaml:"difficulty"`
+	Shuffle    map[types.Difficulty]utils.ScrambleOptions `yaml:"shuffle"`
+	// Length adds to the reward of longer words.
+	Length LengthBonus `yaml:"length"`
+}
+
+// LengthBonus adds PerLetter of the reward for every letter past From.
+type LengthBonus struct {
+	From      int     `yaml:"from"`
+	PerLetter float64 `yaml:"per_letter"`
+}
+
+func (b LengthBonus) Factor(letters int) float64 {
+	if letters <= b.From {
+		return 1
+	}
+	return 1 + b.PerLetter*float64(letters-b.From)
 }
 
 type ScrambleMessageGenerator interface {
+	RoundMessageGenerator
 	FormatScramble(scrambledWord string) string
-	FormatCorrectAnswer(user, answer string, points int) string
-	FormatCloseAnswer(user, guess string, similarity float64) string
-	FormatHint(hint string) string
-	FormatTimeout(answer string) string
-	FormatAlreadyRunning(user string) string
-	FormatStopped() string
-	FormatNoWords() string
 }
 
 type defaultScrambleMessageGenerator struct{}
@@ -68,6 +69,10 @@ func (g *defaultScrambleMessageGenerator) FormatHint(hint string) string {
 	return fmt.Sprintf("[Embaralha] Dica: %s", hint)
 }
 
+func (g *defaultScrambleMessageGenerator) FormatClueHint(hint, definition string) string {
+	return fmt.Sprintf("[Embaralha] Dica: %s (%s)", hint, definition)
+}
+
 func (g *defaultScrambleMessageGenerator) FormatTimeout(answer string) string {
 	return fmt.Sprintf("[Embaralha] Tempo esgotado! Madge A palavra era: %s", answer)
 }
@@ -80,159 +85,76 @@ func (g *defaultScrambleMessageGenerator) FormatStopped() string {
 	return "[Embaralha] MrDestructoid Scramble parou."
 }
 
-func (g *defaultScrambleMessageGenerator) FormatNoWords() string {
+func (g *defaultScrambleMessageGenerator) FormatNoPuzzle(topic string) string {
 	return "[Embaralha] Nenhuma palavra disponível."
 }
 
-func NewScrambleManager(database types.ScrambleDatabase, config ScrambleConfig) *ScrambleManager {
-	return &ScrambleManager{
-		game:       &ScrambleGame{},
+func NewScrambleManager(database types.ScrambleDatabase, rewards *RewardsService, config ScrambleConfig, clock types.Clock) *ScrambleManager {
+	sm := &ScrambleManager{
 		database:   database,
 		config:     config,
 		messageGen: &defaultScrambleMessageGenerator{},
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
+	sm.RoundEngine = NewRoundEngine[types.ScrambleWord]("Scramble", types.ReasonScramble, sm, sm.messageGen, config.RoundConfig, rewards, clock)
+	return sm
 }
 
-func (sm *ScrambleManager) StartScramble(client *twitch.Client, message twitch.PrivateMessage) {
-	if sm.game.Active {
-		if time.Since(sm.game.StartTime) > 5*time.Second {
-			client.Say(message.Channel, sm.messageGen.FormatAlreadyRunning(message.User.DisplayName))
-		}
-		return
-	}
-
-	if time.Since(sm.game.LastStarted) < sm.config.Cooldown {
-		log.Printf("Scramble command blocked -- in silent cooldown.")
-		return
-	}
-
-	word := sm.database.GetRandomWord()
-	if word == nil {
-		client.Say(message.Channel, sm.messageGen.FormatNoWords())
-		return
-	}
-
-	scrambledWord := utils.ScrambleString(word.Word)
-
-	// Ensure the scrambled word is actually different from the original
-	if !utils.ValidateScrambledWord(word.Word, scrambledWord) {
-		// If still the same, try again
-		scrambledWord = utils.ScrambleString(word.Word)
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), sm.config.Timeout)
-	sm.game = &ScrambleGame{
-		Active:        true,
-		Word:          *word,
-		ScrambledWord: scrambledWord,
-		StartTime:     time.Now(),
-		LastStarted:   time.Now(),
-		HintGiven:     false,
-		ctx:           ctx,
-		cancel:        cancel,
-	}
-
-	client.Say(message.Channel, sm.messageGen.FormatScramble(scrambledWord))
-
-	log.Printf("Scramble Word: %s", word.Word)
-	log.Printf("Scrambled: %s", scrambledWord)
-	log.Printf("Scramble ID: %s", word.ID)
-
-	go sm.manageTimer(client, message.Channel)
+func (sm *ScrambleManager) StartScramble(sender types.Sender, message types.ChatMessage) {
+	sm.Start(sender, message, "")
 }
 
-func (sm *ScrambleManager) StopScramble(client *twitch.Client, message twitch.PrivateMessage) {
-	if sm.game.Active {
-		sm.stopGame()
-		client.Say(message.Channel, sm.messageGen.FormatStopped())
-		log.Println("Scramble stopped by moderator")
-	}
+func (sm *ScrambleManager) StopScramble(sender types.Sender, message types.ChatMessage) {
+	sm.StopRound(sender, message)
 }
 
-func (sm *ScrambleManager) CheckAnswer(client *twitch.Client, message twitch.PrivateMessage, checkFunc func(string, string) (bool, float64)) {
-	if !sm.game.Active || len(message.Message) > sm.config.MaxLength {
-		return
-	}
-
-	correct, similarity := checkFunc(message.Message, sm.game.Word.Word)
-
-	if correct {
-		sm.handleCorrectAnswer(client, message, similarity)
-	} else if similarity >= 0.75 && len(message.Message) < sm.config.MaxLength {
-		sm.handleCloseAnswer(client, message, similarity)
-	}
-}
-
-func (sm *ScrambleManager) IsActive() bool {
-	return sm.game.Active
-}
-
-func (sm *ScrambleManager) GetCurrentWord() *types.ScrambleWord {
-	if sm.game.Active {
-		return &sm.game.Word
+// Draw scrambles a word, scaling its reward for length and difficulty. The
+// definition, if any, comes with the first hint.
+func (sm *ScrambleManager) Draw(topic string) (Puzzle[types.ScrambleWord], bool) {
+	word, scrambled := sm.pickWord()
+	if word == nil {
+		return Puzzle[types.ScrambleWord]{}, false
 	}
-	return nil
-}
-
-func (sm *ScrambleManager) manageTimer(client *twitch.Client, channel string) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
 
-	for sm.game.Active {
-		select {
-		case <-sm.game.ctx.Done():
-			return
-		case <-ticker.C:
-			elapsed := time.Since(sm.game.StartTime)
-
-			if elapsed >= sm.config.HintTime && !sm.game.HintGiven && sm.game.Active {
-				sm.giveHint(client, channel)
-			}
-
-			if elapsed >= sm.config.Timeout && sm.game.Active {
-				sm.handleTimeout(client, channel)
-				return
-			}
+	log.Printf("Scramble Word: %s", word.Word)
+	log.Printf("Scrambled: %s", scrambled)
+	log.Printf("Scramble ID: %s (%s)", word.ID, word.Difficulty.Level())
+
+	scale := ScaleFor(sm.config.Difficulty, word.Difficulty)
+	lengthFactor := sm.config.Length.Factor(utils.LetterCount(word.Word))
+	return Puzzle[types.ScrambleWord]{
+		Item:    *word,
+		ID:      word.ID,
+		Prompt:  sm.messageGen.FormatScramble(scrambled),
+		Answer:  word.Word,
+		Clue:    word.Definition,
+		Reward:  sm.config.Reward.Scaled(scale.Reward * lengthFactor),
+		Timeout: scale.Duration(sm.config.Timeout),
+	}, true
+}
+
+func (sm *ScrambleManager) Check(guess string, word types.ScrambleWord) (bool, float64) {
+	return sm.config.Match.CheckScrambleGuess(guess, word.Word)
+}
+
+// pickWord draws words until one can be scrambled, giving up after a few
+// tries.
+func (sm *ScrambleManager) pickWord() (*types.ScrambleWord, string) {
+	for range maxScrambleDraws {
+		word := sm.database.GetRandomWord()
+		if word == nil {
+			return nil, ""
 		}
-	}
-}
-
-func (sm *ScrambleManager) giveHint(client *twitch.Client, channel string) {
-	sm.game.HintGiven = true
-	hint := utils.GenerateScrambleHint(sm.game.Word.Word)
-	client.Say(channel, sm.messageGen.FormatHint(hint))
-}
-
-func (sm *ScrambleManager) handleTimeout(client *twitch.Client, channel string) {
-	sm.stopGame()
-	client.Say(channel, sm.messageGen.FormatTimeout(sm.game.Word.Word))
-	log.Printf("Scramble timeout - Answer was: %s", sm.game.Word.Word)
-}
-
-func (sm *ScrambleManager) handleCorrectAnswer(client *twitch.Client, message twitch.PrivateMessage, similarity float64) {
-	sm.stopGame()
 
-	points := 6
-	if similarity >= 0.95 {
-		points = 8
+		opts := sm.config.Shuffle[word.Difficulty.Level()]
+		if scrambled, ok := utils.ScrambleWords(sm.rng, word.Word, opts); ok {
+			return word, scrambled
+		}
+		log.Printf("[Scramble] Word %s (%q) can't be scrambled, skipping", word.ID, word.Word)
 	}
-
-	client.Say(message.Channel, sm.messageGen.FormatCorrectAnswer(
-		message.User.DisplayName, sm.game.Word.Word, points))
-
-	log.Printf("[Scramble] %s answered correctly with similarity %.2f",
-		message.User.DisplayName, similarity)
+	return nil, ""
 }
 
-func (sm *ScrambleManager) handleCloseAnswer(client *twitch.Client, message twitch.PrivateMessage, similarity float64) {
-	client.Say(message.Channel, sm.messageGen.FormatCloseAnswer(
-		message.User.DisplayName, message.Message, similarity))
-	log.Printf("[Scramble] %s is close (%.0f%%)", message.User.DisplayName, similarity*100)
-}
-
-func (sm *ScrambleManager) stopGame() {
-	if sm.game.cancel != nil {
-		sm.game.cancel()
-	}
-	sm.game.Active = false
+func (sm *ScrambleManager) GetCurrentWord() *types.ScrambleWord {
+	return sm.Current()
 }
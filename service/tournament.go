@@ -0,0 +1,329 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"twitchgo/types"
+)
+
+type TournamentConfig struct {
+	// Break is the pause between one question and the next.
+	Break     time.Duration `yaml:"break"`
+	MaxRounds int           `yaml:"max_rounds"`
+	// PodiumBonus is paid to the top finishers, first place first.
+	PodiumBonus []int `yaml:"podium_bonus"`
+}
+
+type TournamentScore struct {
+	Username    string
+	DisplayName string
+	Points      int
+	Correct     int
+	// reached orders ties: whoever got to the score first ranks higher.
+	reached int
+}
+
+// Tournament is a run of consecutive trivia rounds sharing a scoreboard.
+type Tournament struct {
+	Rounds     int
+	Round      int
+	Category   string
+	Paused     bool
+	scores     map[string]*TournamentScore
+	events     int
+	breakTimer types.Timer
+}
+
+func (t *Tournament) score(user types.ChatUser, points int) {
+	entry, ok := t.scores[user.Name]
+	if !ok {
+		entry = &TournamentScore{Username: user.Name, DisplayName: user.DisplayName}
+		t.scores[user.Name] = entry
+	}
+
+	t.events++
+	entry.Points += points
+	entry.Correct++
+	entry.reached = t.events
+}
+
+// Standings returns the scoreboard, best first.
+func (t *Tournament) Standings() []TournamentScore {
+	standings := make([]TournamentScore, 0, len(t.scores))
+	for _, entry := range t.scores {
+		standings = append(standings, *entry)
+	}
+
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		return standings[i].reached < standings[j].reached
+	})
+	return standings
+}
+
+func (t *Tournament) stopBreak() {
+	if t.breakTimer != nil {
+		t.breakTimer.Stop()
+		t.breakTimer = nil
+	}
+}
+
+type TournamentMessageGenerator interface {
+	FormatTournamentStart(rounds int) string
+	FormatRound(round, rounds int, question string) string
+	FormatStandings(round, rounds int, standings []TournamentScore) string
+	FormatPodium(standings []TournamentScore, bonuses []int) string
+	FormatSkipped(answer string) string
+	FormatPaused() string
+	FormatResumed() string
+	FormatNoTournament() string
+}
+
+func (g *defaultMessageGenerator) FormatTournamentStart(rounds int) string {
+	return fmt.Sprintf("[Quiz] 🏆 Torneio de %d perguntas começando! Gayge Clap", rounds)
+}
+
+func (g *defaultMessageGenerator) FormatRound(round, rounds int, question string) string {
+	return fmt.Sprintf("Chatting [Quiz %d/%d] %s Gayge Clap", round, rounds, question)
+}
+
+func (g *defaultMessageGenerator) FormatStandings(round, rounds int, standings []TournamentScore) string {
+	if len(standings) == 0 {
+		return fmt.Sprintf("[Quiz] Placar após %d/%d: ninguém pontuou ainda.", round, rounds)
+	}
+
+	if len(standings) > 5 {
+		standings = standings[:5]
+	}
+	parts := make([]string, len(standings))
+	for i, entry := range standings {
+		parts[i] = fmt.Sprintf("%d. %s (%d)", i+1, entry.DisplayName, entry.Points)
+	}
+	return fmt.Sprintf("[Quiz] Placar após %d/%d: %s", round, rounds, strings.Join(parts, ", "))
+}
+
+func (g *defaultMessageGenerator) FormatPodium(standings []TournamentScore, bonuses []int) string {
+	if len(standings) == 0 {
+		return "[Quiz] 🏆 Torneio encerrado! Ninguém pontuou. Madge"
+	}
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	var parts []string
+	for i, entry := range standings {
+		if i >= len(medals) {
+			break
+		}
+		part := fmt.Sprintf("%s %s (%d", medals[i], entry.DisplayName, entry.Points)
+		if i < len(bonuses) && bonuses[i] > 0 {
+			part += fmt.Sprintf(" +%d", bonuses[i])
+		}
+		parts = append(parts, part+")")
+	}
+	return fmt.Sprintf("[Quiz] 🏆 Torneio encerrado! %s", strings.Join(parts, " "))
+}
+
+func (g *defaultMessageGenerator) FormatSkipped(answer string) string {
+	return fmt.Sprintf("[Quiz] Pergunta pulada. A resposta era: %s", answer)
+}
+
+func (g *defaultMessageGenerator) FormatPaused() string {
+	return "[Quiz] ⏸️ Torneio pausado."
+}
+
+func (g *defaultMessageGenerator) FormatResumed() string {
+	return "[Quiz] ▶️ Torneio retomado."
+}
+
+func (g *defaultMessageGenerator) FormatNoTournament() string {
+	return "[Quiz] Nenhum torneio em andamento."
+}
+
+// StartTournament runs rounds consecutive questions from category, or from
+// any category when it is empty.
+func (tm *TriviaManager) StartTournament(sender types.Sender, message types.ChatMessage, rounds int, category string) {
+	tm.mutex.Lock()
+	defer tm.unlock()
+
+	if tm.round.Active || tm.tournament != nil {
+		sender.Say(message.Channel, tm.messageGen.FormatAlreadyRunning(message.User.DisplayName))
+		return
+	}
+
+	if limit := tm.config.Tournament.MaxRounds; limit > 0 && rounds > limit {
+		rounds = limit
+	}
+
+	tm.tournament = &Tournament{
+		Rounds:   rounds,
+		Category: category,
+		scores:   make(map[string]*TournamentScore),
+	}
+
+	sender.Say(message.Channel, tm.messageGen.FormatTournamentStart(rounds))
+	log.Printf("[Trivia] Tournament of %d rounds started by %s", rounds, message.User.Name)
+
+	tm.nextRound(sender, message.Channel)
+}
+
+// PauseTournament keeps the next question from starting. A question already
+// running is played out.
+func (tm *TriviaManager) PauseTournament(sender types.Sender, message types.ChatMessage) {
+	tm.mutex.Lock()
+	defer tm.unlock()
+
+	t := tm.tournament
+	if t == nil {
+		sender.Say(message.Channel, tm.messageGen.FormatNoTournament())
+		return
+	}
+
+	if !t.Paused {
+		t.Paused = true
+		t.stopBreak()
+	}
+	sender.Say(message.Channel, tm.messageGen.FormatPaused())
+}
+
+func (tm *TriviaManager) ResumeTournament(sender types.Sender, message types.ChatMessage) {
+	tm.mutex.Lock()
+	defer tm.unlock()
+
+	t := tm.tournament
+	if t == nil {
+		sender.Say(message.Channel, tm.messageGen.FormatNoTournament())
+		return
+	}
+
+	if !t.Paused {
+		return
+	}
+
+	t.Paused = false
+	sender.Say(message.Channel, tm.messageGen.FormatResumed())
+	if !tm.round.Active {
+		tm.scheduleNextRound(sender, message.Channel)
+	}
+}
+
+// SkipRound reveals the current answer without scoring it, or cuts the break
+// short when no question is running.
+func (tm *TriviaManager) SkipRound(sender types.Sender, message types.ChatMessage) {
+	tm.mutex.Lock()
+	defer tm.unlock()
+
+	t := tm.tournament
+	if t == nil {
+		sender.Say(message.Channel, tm.messageGen.FormatNoTournament())
+		return
+	}
+
+	if tm.round.Active {
+		tm.stopRound()
+		sender.Say(message.Channel, tm.messageGen.FormatSkipped(tm.round.Puzzle.Answer))
+		tm.endRound(sender, message.Channel, nil, 0)
+		return
+	}
+
+	t.stopBreak()
+	t.Paused = false
+	tm.nextRound(sender, message.Channel)
+}
+
+// EndTournament stops the tournament early and hands out the podium for the
+// rounds played so far.
+func (tm *TriviaManager) EndTournament(sender types.Sender, message types.ChatMessage) {
+	tm.mutex.Lock()
+	defer tm.unlock()
+
+	if tm.tournament == nil {
+		sender.Say(message.Channel, tm.messageGen.FormatNoTournament())
+		return
+	}
+
+	if tm.round.Active {
+		tm.stopRound()
+	}
+	tm.finishTournament(sender, message.Channel)
+}
+
+// endRound moves a tournament on after a question ended, answered or not;
+// callers hold the mutex.
+func (tm *TriviaManager) endRound(sender types.Sender, channel string, winner *types.ChatUser, points int) {
+	t := tm.tournament
+	if t == nil {
+		return
+	}
+
+	if winner != nil {
+		t.score(*winner, points)
+	}
+
+	if t.Round >= t.Rounds {
+		tm.finishTournament(sender, channel)
+		return
+	}
+
+	sender.Say(channel, tm.messageGen.FormatStandings(t.Round, t.Rounds, t.Standings()))
+	if !t.Paused {
+		tm.scheduleNextRound(sender, channel)
+	}
+}
+
+func (tm *TriviaManager) scheduleNextRound(sender types.Sender, channel string) {
+	t := tm.tournament
+	t.stopBreak()
+	t.breakTimer = tm.clock.AfterFunc(tm.config.Tournament.Break, func() {
+		tm.mutex.Lock()
+		defer tm.unlock()
+
+		if tm.tournament == t && !t.Paused && !tm.round.Active {
+			t.breakTimer = nil
+			tm.nextRound(sender, channel)
+		}
+	})
+}
+
+func (tm *TriviaManager) nextRound(sender types.Sender, channel string) {
+	t := tm.tournament
+	t.Round++
+	if !tm.open(sender, channel, t.Category) {
+		t.Round--
+		tm.finishTournament(sender, channel)
+	}
+}
+
+func (tm *TriviaManager) finishTournament(sender types.Sender, channel string) {
+	t := tm.tournament
+	t.stopBreak()
+	tm.tournament = nil
+
+	standings := t.Standings()
+	bonuses := tm.config.Tournament.PodiumBonus
+	for i, entry := range standings {
+		if i >= len(bonuses) {
+			break
+		}
+		payout := Payout{Username: entry.Username, Amount: bonuses[i], Reason: types.ReasonTournament}
+		if err := tm.rewards.Pay(payout); err != nil {
+			log.Printf("[Trivia] %v", err)
+		}
+	}
+
+	sender.Say(channel, tm.messageGen.FormatPodium(standings, bonuses))
+	log.Printf("[Trivia] Tournament finished after %d of %d rounds", t.Round, t.Rounds)
+}
+
+// cancelTournament drops the tournament without a podium; callers hold the
+// mutex.
+func (tm *TriviaManager) cancelTournament() {
+	if tm.tournament != nil {
+		tm.tournament.stopBreak()
+		tm.tournament = nil
+	}
+}
@@ -1,50 +1,32 @@
 package service
 
 import (
-	"context"
 	"fmt"
 	"log"
-	"time"
 
 	"twitchgo/types"
-	"twitchgo/utils"
-
-	"github.com/gempir/go-twitch-irc/v4"
 )
 
-type TriviaGame struct {
-	Active      bool
-	Question    types.TriviaQuestion
-	StartTime   time.Time
-	HintGiven   bool
-	LastStarted time.Time
-	ctx         context.Context
-	cancel      context.CancelFunc
-}
-
+// TriviaManager is trivia on the RoundEngine, plus tournaments.
 type TriviaManager struct {
-	game       *TriviaGame
+	*RoundEngine[types.TriviaQuestion]
+	tournament *Tournament
 	database   types.TriviaDatabase
 	config     TriviaConfig
 	messageGen MessageGenerator
 }
 
 type TriviaConfig struct {
-	Cooldown  time.Duration
-	HintTime  time.Duration
-	Timeout   time.Duration
-	MaxLength int
+	RoundConfig `yaml:",inline"`
+	// Difficulty scales Reward and Timeout per question.
+	Difficulty map[types.Difficulty]DifficultyScale `yaml:"difficulty"`
+	Tournament TournamentConfig                     `yaml:"tournament"`
 }
 
 type MessageGenerator interface {
+	RoundMessageGenerator
 	FormatQuestion(question string) string
-	FormatCorrectAnswer(user, answer string, points int) string
-	FormatCloseAnswer(user, guess string, similarity float64) string
-	FormatHint(hint string) string
-	FormatTimeout(answer string) string
-	FormatAlreadyRunning(user string) string
-	FormatStopped() string
-	FormatNoQuestions() string
+	TournamentMessageGenerator
 }
 
 type defaultMessageGenerator struct{}
@@ -67,6 +49,10 @@ func (g *defaultMessageGenerator) FormatHint(hint string) string {
 	return fmt.Sprintf("[Quiz] Dica: %s", hint)
 }
 
+func (g *defaultMessageGenerator) FormatClueHint(hint, clue string) string {
+	return fmt.Sprintf("[Quiz] Dica: %s (%s)", hint, clue)
+}
+
 func (g *defaultMessageGenerator) FormatTimeout(answer string) string {
 	return fmt.Sprintf("[Quiz] Ninguém respondeu corretamente. Madge A resposta era: %s", answer)
 }
@@ -79,150 +65,94 @@ func (g *defaultMessageGenerator) FormatStopped() string {
 	return "[Quiz] MrDestructoid Quiz parou."
 }
 
-func (g *defaultMessageGenerator) FormatNoQuestions() string {
+func (g *defaultMessageGenerator) FormatNoPuzzle(category string) string {
+	if category != "" {
+		return fmt.Sprintf("[Quiz] Nenhuma pergunta disponível na categoria %s.", category)
+	}
 	return "[Quiz] Nenhuma pergunta disponível."
 }
 
-func NewTriviaManager(database types.TriviaDatabase, config TriviaConfig) *TriviaManager {
-	return &TriviaManager{
-		game:       &TriviaGame{},
+func NewTriviaManager(database types.TriviaDatabase, rewards *RewardsService, config TriviaConfig, clock types.Clock) *TriviaManager {
+	tm := &TriviaManager{
 		database:   database,
 		config:     config,
 		messageGen: &defaultMessageGenerator{},
 	}
+	tm.RoundEngine = NewRoundEngine[types.TriviaQuestion]("Trivia", types.ReasonTrivia, tm, tm.messageGen, config.RoundConfig, rewards, clock)
+	tm.onRoundEnd = tm.endRound
+	tm.betweenRounds = func() bool { return tm.tournament != nil }
+	return tm
 }
 
-func (tm *TriviaManager) StartTrivia(client *twitch.Client, message twitch.PrivateMessage) {
-	if tm.game.Active {
-		if time.Since(tm.game.StartTime) > 5*time.Second {
-			client.Say(message.Channel, tm.messageGen.FormatAlreadyRunning(message.User.DisplayName))
-		}
-		return
-	}
+// StartTrivia asks a question from category, or from any category when it is
+// empty.
+func (tm *TriviaManager) StartTrivia(sender types.Sender, message types.ChatMessage, category string) {
+	tm.mutex.Lock()
+	defer tm.unlock()
 
-	if time.Since(tm.game.LastStarted) < tm.config.Cooldown {
-		log.Printf("Trivia command blocked -- in silent cooldown.")
+	if tm.tournament != nil {
+		sender.Say(message.Channel, tm.messageGen.FormatAlreadyRunning(message.User.DisplayName))
 		return
 	}
 
-	question := tm.database.GetRandomQuestion()
-	if question == nil {
-		client.Say(message.Channel, tm.messageGen.FormatNoQuestions())
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), tm.config.Timeout)
-	tm.game = &TriviaGame{
-		Active:      true,
-		Question:    *question,
-		StartTime:   time.Now(),
-		LastStarted: time.Now(),
-		HintGiven:   false,
-		ctx:         ctx,
-		cancel:      cancel,
-	}
-
-	client.Say(message.Channel, tm.messageGen.FormatQuestion(question.Question))
-
-	log.Printf("Trivia Question: %s", question.Question)
-	log.Printf("Trivia Answer: %s", question.Answer)
-	log.Printf("Trivia QID: %s", question.ID)
-
-	go tm.manageTimer(client, message.Channel)
+	tm.start(sender, message, category)
 }
 
-func (tm *TriviaManager) StopTrivia(client *twitch.Client, message twitch.PrivateMessage) {
-	if tm.game.Active {
-		tm.stopGame()
-		client.Say(message.Channel, tm.messageGen.FormatStopped())
-		log.Println("Trivia stopped by moderator")
+// Draw picks a question from category and scales it for its difficulty.
+func (tm *TriviaManager) Draw(category string) (Puzzle[types.TriviaQuestion], bool) {
+	question := tm.database.GetRandomQuestionIn(category)
+	if question == nil {
+		return Puzzle[types.TriviaQuestion]{}, false
 	}
-}
 
-func (tm *TriviaManager) CheckAnswer(client *twitch.Client, message twitch.PrivateMessage, checkFunc func(string, string) (bool, float64)) {
-	if !tm.game.Active || len(message.Message) > tm.config.MaxLength {
-		return
+	prompt := tm.messageGen.FormatQuestion(question.Question)
+	if t := tm.tournament; t != nil {
+		prompt = tm.messageGen.FormatRound(t.Round, t.Rounds, question.Question)
 	}
 
-	correct, similarity := checkFunc(message.Message, tm.game.Question.Answer)
-
-	if correct {
-		tm.handleCorrectAnswer(client, message, similarity)
-	} else if similarity >= 0.82 && len(message.Message) < tm.config.MaxLength {
-		tm.handleCloseAnswer(client, message, similarity)
-	}
-}
+	log.Printf("Trivia Question: %s", question.Question)
+	log.Printf("Trivia QID: %s (%s, %s)", question.ID, question.Category, question.Difficulty.Level())
 
-func (tm *TriviaManager) IsActive() bool {
-	return tm.game.Active
+	scale := ScaleFor(tm.config.Difficulty, question.Difficulty)
+	return Puzzle[types.TriviaQuestion]{
+		Item:    *question,
+		ID:      question.ID,
+		Prompt:  prompt,
+		Answer:  question.Answer,
+		Reward:  tm.config.Reward.Scaled(scale.Reward),
+		Timeout: scale.Duration(tm.config.Timeout),
+	}, true
 }
 
-func (tm *TriviaManager) GetCurrentQuestion() *types.TriviaQuestion {
-	if tm.game.Active {
-		return &tm.game.Question
-	}
-	return nil
+func (tm *TriviaManager) Check(guess string, question types.TriviaQuestion) (bool, float64) {
+	return tm.config.Match.CheckTriviaGuess(guess, question)
 }
 
-func (tm *TriviaManager) manageTimer(client *twitch.Client, channel string) {
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for tm.game.Active {
-		select {
-		case <-tm.game.ctx.Done():
-			return
-		case <-ticker.C:
-			elapsed := time.Since(tm.game.StartTime)
+func (tm *TriviaManager) StopTrivia(sender types.Sender, message types.ChatMessage) {
+	tm.mutex.Lock()
+	defer tm.unlock()
 
-			if elapsed >= tm.config.HintTime && !tm.game.HintGiven && tm.game.Active {
-				tm.giveHint(client, channel)
-			}
-
-			if elapsed >= tm.config.Timeout && tm.game.Active {
-				tm.handleTimeout(client, channel)
-				return
-			}
+	if tm.round.Active || tm.tournament != nil {
+		if tm.round.Active {
+			tm.stopRound()
 		}
+		tm.cancelTournament()
+		sender.Say(message.Channel, tm.messageGen.FormatStopped())
+		log.Println("Trivia stopped by moderator")
 	}
 }
 
-func (tm *TriviaManager) giveHint(client *twitch.Client, channel string) {
-	tm.game.HintGiven = true
-	hint := utils.GenerateHint(tm.game.Question.Answer)
-	client.Say(channel, tm.messageGen.FormatHint(hint))
-}
-
-func (tm *TriviaManager) handleTimeout(client *twitch.Client, channel string) {
-	tm.stopGame()
-	client.Say(channel, tm.messageGen.FormatTimeout(tm.game.Question.Answer))
-	log.Printf("Trivia timeout - Answer was: %s", tm.game.Question.Answer)
-}
+// Stop ends the running game and tournament without announcing it.
+func (tm *TriviaManager) Stop() {
+	tm.mutex.Lock()
+	defer tm.unlock()
 
-func (tm *TriviaManager) handleCorrectAnswer(client *twitch.Client, message twitch.PrivateMessage, similarity float64) {
-	tm.stopGame()
-
-	points := 8
-	if similarity >= 0.92 {
-		points = 10
+	if tm.round.Active {
+		tm.stopRound()
 	}
-
-	client.Say(message.Channel, tm.messageGen.FormatCorrectAnswer(
-		message.User.DisplayName, tm.game.Question.Answer, points))
-
-	log.Printf("[Trivia] %s answered correctly with similarity %.2f",
-		message.User.DisplayName, similarity)
-}
-
-func (tm *TriviaManager) handleCloseAnswer(client *twitch.Client, message twitch.PrivateMessage, similarity float64) {
-	client.Say(message.Channel, tm.messageGen.FormatCloseAnswer(
-		message.User.DisplayName, message.Message, similarity))
-	log.Printf("[Trivia] %s is close (%.0f%%)", message.User.DisplayName, similarity*100)
+	tm.cancelTournament()
 }
 
-func (tm *TriviaManager) stopGame() {
-	if tm.game.cancel != nil {
-		tm.game.cancel()
-	}
-	tm.game.Active = false
+func (tm *TriviaManager) GetCurrentQuestion() *types.TriviaQuestion {
+	return tm.Current()
 }
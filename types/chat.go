@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+type ChatUser struct {
+	ID          string
+	Name        string
+	DisplayName string
+	Badges      map[string]int
+}
+
+type ChatMessage struct {
+	ID      string
+	Channel string
+	User    ChatUser
+	Text    string
+	Time    time.Time
+}
+
+// Sender is the outgoing side of a chat connection.
+type Sender interface {
+	Say(channel, text string)
+	Reply(channel, parentID, text string)
+	Whisper(username, text string)
+}
+
+// ChannelJoiner is implemented by transports that can join and leave
+// channels at runtime.
+type ChannelJoiner interface {
+	Join(channels ...string)
+	Depart(channel string)
+}
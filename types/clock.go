@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// Clock is the source of time for games, cooldowns and daily rewards, so
+// their schedules can be driven by a fake clock.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f once d has elapsed. The system clock calls it in its
+	// own goroutine; a fake clock may call it synchronously from whatever
+	// moves the clock, so f must not rely on either.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+type Timer interface {
+	// Stop prevents the timer from firing. It reports false if the timer
+	// already fired or was stopped.
+	Stop() bool
+}
@@ -0,0 +1,33 @@
+package types
+
+import "strings"
+
+// Difficulty grades a trivia question or scramble word. An empty difficulty
+// counts as DifficultyMedium.
+type Difficulty string
+
+const (
+	DifficultyEasy   Difficulty = "facil"
+	DifficultyMedium Difficulty = "medio"
+	DifficultyHard   Difficulty = "dificil"
+)
+
+// ParseDifficulty accepts the canonical names, their accented spellings and
+// the English ones.
+func ParseDifficulty(s string) (Difficulty, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "medio", "médio", "medium":
+		return DifficultyMedium, true
+	case "facil", "fácil", "easy":
+		return DifficultyEasy, true
+	case "dificil", "difícil", "hard":
+		return DifficultyHard, true
+	}
+	return DifficultyMedium, false
+}
+
+// Level returns d, or DifficultyMedium when d is empty or unknown.
+func (d Difficulty) Level() Difficulty {
+	level, _ := ParseDifficulty(string(d))
+	return level
+}
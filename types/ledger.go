@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+type LedgerEntry struct {
+	ID           int64     `json:"id"`
+	Time         time.Time `json:"time"`
+	Username     string    `json:"username"`
+	Delta        int       `json:"delta"`
+	Balance      int       `json:"balance"`
+	Reason       Reason    `json:"reason"`
+	Counterparty string    `json:"counterparty,omitempty"`
+	// Streak is the daily streak a daily claim reached.
+	Streak int `json:"streak,omitempty"`
+}
+
+type LedgerAudit struct {
+	Username string
+	Entries  int
+	Replayed int
+	Balance  int
+}
+
+func (a LedgerAudit) Consistent() bool {
+	return a.Replayed == a.Balance
+}
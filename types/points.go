@@ -1,23 +1,71 @@
 package types
 
+import (
+	"errors"
+	"time"
+)
+
 type UserData struct {
-	Username   string `json:"username"`
-	Points     int    `json:"points"`
-	GambleLoss int    `json:"gamble_loss"`
+	Username    string    `json:"username"`
+	Points      int       `json:"points"`
+	GambleLoss  int       `json:"gamble_loss"`
+	LastDaily   time.Time `json:"last_daily,omitzero"`
+	DailyStreak int       `json:"daily_streak,omitempty"`
+}
+
+var (
+	ErrInsufficientPoints = errors.New("insufficient points")
+	ErrSelfTransfer       = errors.New("cannot transfer to yourself")
+)
+
+type DailyConfig struct {
+	Amount         int            `yaml:"amount"`
+	StreakBonus    int            `yaml:"streak_bonus"`
+	MaxStreakBonus int            `yaml:"max_streak_bonus"`
+	Period         time.Duration  `yaml:"period"`
+	ResetHour      int            `yaml:"reset_hour"`
+	Location       *time.Location `yaml:"-"`
+}
+
+type DailyClaim struct {
+	Claimed   bool
+	Amount    int
+	Bonus     int
+	Streak    int
+	Balance   int
+	NextClaim time.Time
 }
 
 type PointsDatabase interface {
 	GetPoints(username string) int
-	AddPoints(username string, amount int) error
-	SubtractPoints(username string, amount int) error
+	AddPoints(username string, amount int, reason Reason) error
+	SubtractPoints(username string, amount int, reason Reason) error
 	GetGambleLoss(username string) int
 	AddGambleLoss(username string, amount int) error
 	TransferPoints(sender, receiver string, amount int) error
 	Gamble(username string, wager int, format string, winOdds float64) (string, int, int, error)
+	ClaimDaily(username string, now time.Time, config DailyConfig) (DailyClaim, error)
 	GetTopPoints(limit int) ([]string, []int)
 	GetTopGambleLoss(limit int) ([]string, []int)
 	GetRank(username string) (int, int)
+	GetHistory(username string, limit int) []LedgerEntry
+	AuditUser(username string) (LedgerAudit, error)
 	ValidateUser(username string) error
 	SaveToFile() error
 	LoadFromFile() error
+	Close() error
 }
+
+type Reason string
+
+const (
+	ReasonGamble     Reason = "gamble"
+	ReasonTransfer   Reason = "transfer"
+	ReasonDaily      Reason = "daily"
+	ReasonTrivia     Reason = "trivia"
+	ReasonScramble   Reason = "scramble"
+	ReasonTournament Reason = "tournament"
+	ReasonAdmin      Reason = "admin"
+	// ReasonOpening carries balances that existed before the ledger did.
+	ReasonOpening Reason = "opening"
+)
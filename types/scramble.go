@@ -1,12 +1,29 @@
 package types
 
 type ScrambleWord struct {
-	ID      string `json:"id"`
-	Word    string `json:"word"`
-	Enabled bool   `json:"enabled"`
+	ID         string     `json:"id"`
+	Word       string     `json:"word"`
+	Category   string     `json:"category,omitempty"`
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+	// Definition, when set, is shown to chat as the first hint.
+	Definition string `json:"definition,omitempty"`
+	Enabled    bool   `json:"enabled"`
 }
 
 type ScrambleDatabase interface {
 	GetRandomWord() *ScrambleWord
+	// GetWord finds a word by its ID or, failing that, by the word itself.
+	GetWord(idOrWord string) *ScrambleWord
+	// ListWords returns every word in category, enabled or not; an empty
+	// category means all of them.
+	ListWords(category string) []ScrambleWord
+	// AddWord stores word, assigning it an ID when it has none, and returns
+	// the ID it was stored under.
+	AddWord(word ScrambleWord) string
+	EnableWord(idOrWord string) bool
+	DisableWord(idOrWord string) bool
+	GetWordCount() int
+	GetEnabledWordCount() int
+	SaveToJSONFile(filename string) error
 	ReloadWords() error
 }
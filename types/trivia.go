@@ -1,16 +1,45 @@
 package types
 
 type TriviaQuestion struct {
-	ID       string
-	Question string
-	Answer   string
-	Enabled  bool
+	ID       string `json:"id"`
+	Question string `json:"question"`
+	// Answer is the answer revealed to chat. Answers lists other spellings
+	// and nicknames that also count, and Patterns holds regular expressions
+	// that a whole guess may match instead.
+	Answer     string     `json:"answer"`
+	Answers    []string   `json:"answers,omitempty"`
+	Patterns   []string   `json:"patterns,omitempty"`
+	Category   string     `json:"category,omitempty"`
+	Difficulty Difficulty `json:"difficulty,omitempty"`
+	Enabled    bool       `json:"enabled"`
+}
+
+// AcceptedAnswers returns Answer followed by its alternatives.
+func (q TriviaQuestion) AcceptedAnswers() []string {
+	answers := make([]string, 0, len(q.Answers)+1)
+	if q.Answer != "" {
+		answers = append(answers, q.Answer)
+	}
+	for _, answer := range q.Answers {
+		if answer != "" && answer != q.Answer {
+			answers = append(answers, answer)
+		}
+	}
+	return answers
 }
 
 type TriviaDatabase interface {
 	GetRandomQuestion() *TriviaQuestion
+	// GetRandomQuestionIn draws from one category; an empty category means
+	// any question.
+	GetRandomQuestionIn(category string) *TriviaQuestion
+	// GetCategoryCounts maps each category to its number of enabled
+	// questions.
+	GetCategoryCounts() map[string]int
 	GetQuestionByID(id string) *TriviaQuestion
-	AddQuestion(question TriviaQuestion)
+	// AddQuestion stores question, assigning it an ID when it has none, and
+	// returns the ID it was stored under.
+	AddQuestion(question TriviaQuestion) string
 	EnableQuestion(id string) bool
 	DisableQuestion(id string) bool
 	GetQuestionCount() int
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic replaces path with data without ever exposing a partially
+// written file. The previous contents are kept as path.1 ... path.N.
+func WriteFileAtomic(path string, data []byte, backups int) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := rotateBackups(path, backups); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	return syncDir(dir)
+}
+
+// ReadFileWithBackups returns the contents of path, or of the newest backup
+// accepted by validate when path is missing or invalid. The second value is
+// the file that was actually read.
+func ReadFileWithBackups(path string, backups int, validate func([]byte) error) ([]byte, string, error) {
+	var firstErr error
+
+	for i := 0; i <= backups; i++ {
+		candidate := backupName(path, i)
+
+		data, err := os.ReadFile(candidate)
+		if err == nil {
+			err = validate(data)
+		}
+		if err == nil {
+			return data, candidate, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, "", firstErr
+}
+
+func rotateBackups(path string, backups int) error {
+	if backups <= 0 {
+		return nil
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	for i := backups - 1; i >= 1; i-- {
+		err := os.Rename(backupName(path, i), backupName(path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate backup %d: %w", i, err)
+		}
+	}
+
+	first := backupName(path, 1)
+	os.Remove(first)
+	if err := os.Link(path, first); err != nil {
+		if err := copyFile(path, first); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+func backupName(path string, index int) string {
+	if index == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, index)
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o644)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", dir, err)
+	}
+	defer d.Close()
+
+	// Some filesystems don't support syncing directories; the rename itself
+	// already happened, so that is not worth failing the save over.
+	d.Sync()
+	return nil
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"twitchgo/types"
+)
+
+func readString(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWriteFileAtomicRotatesBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	for _, version := range []string{"v1", "v2", "v3", "v4"} {
+		if err := WriteFileAtomic(path, []byte(version), 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for file, want := range map[string]string{path: "v4", path + ".1": "v3", path + ".2": "v2"} {
+		if got := readString(t, file); got != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(file), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("kept more backups than asked for: %v", err)
+	}
+
+	// Writing leaves no temp files behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("directory holds %d files, want 3", len(entries))
+	}
+}
+
+func TestWriteFileAtomicWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	for _, version := range []string{"v1", "v2"} {
+		if err := WriteFileAtomic(path, []byte(version), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := readString(t, path); got != "v2" {
+		t.Errorf("data.json = %q, want v2", got)
+	}
+	if _, err := os.Stat(path + ".1"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("wrote a backup with backups disabled: %v", err)
+	}
+}
+
+func TestReadFileWithBackups(t *testing.T) {
+	validJSON := func(data []byte) error {
+		var v any
+		return json.Unmarshal(data, &v)
+	}
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		want   string
+		source string
+	}{
+		{"main file", map[string]string{"": `"main"`, ".1": `"one"`}, `"main"`, ""},
+		{"corrupt main", map[string]string{"": `{"broken`, ".1": `"one"`}, `"one"`, ".1"},
+		{"truncated main", map[string]string{"": ``, ".1": `"one"`}, `"one"`, ".1"},
+		{"missing main", map[string]string{".1": `"one"`, ".2": `"two"`}, `"one"`, ".1"},
+		{"corrupt main and first backup", map[string]string{"": `[1,`, ".1": `nul`, ".2": `"two"`}, `"two"`, ".2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.json")
+			for suffix, content := range tt.files {
+				if err := os.WriteFile(path+suffix, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			data, source, err := ReadFileWithBackups(path, 2, validJSON)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want || source != path+tt.source {
+				t.Errorf("read %q from %s, want %q from %s", data, filepath.Base(source), tt.want, filepath.Base(path+tt.source))
+			}
+		})
+	}
+}
+
+func TestReadFileWithBackupsAllInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	for _, suffix := range []string{"", ".1"} {
+		if err := os.WriteFile(path+suffix, []byte("{"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, _, err := ReadFileWithBackups(path, 2, func(data []byte) error {
+		var v any
+		return json.Unmarshal(data, &v)
+	})
+	if err == nil {
+		t.Fatal("read succeeded with every copy corrupt")
+	}
+}
+
+// TestPointsLoadFromBackup corrupts user_data.json and checks that the
+// newest valid backup is loaded. Each open gets a fresh ledger so the
+// snapshot alone decides the balance.
+func TestPointsLoadFromBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user_data.json")
+
+	db, err := NewInMemoryPointsDB(path, filepath.Join(dir, "ledger.jsonl"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := db.AddPoints("viewer", 10, types.ReasonAdmin); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SaveToFile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	reopen := func(ledger string) int {
+		t.Helper()
+		db, err := NewInMemoryPointsDB(path, filepath.Join(dir, ledger), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		return db.GetPoints("viewer")
+	}
+
+	// A save cut short leaves half a file behind.
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if points := reopen("ledger2.jsonl"); points != 20 {
+		t.Fatalf("loaded %d points with user_data.json truncated, want 20 from the first backup", points)
+	}
+
+	if err := os.WriteFile(path+".1", []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if points := reopen("ledger3.jsonl"); points != 10 {
+		t.Fatalf("loaded %d points with the first backup corrupt too, want 10 from the second", points)
+	}
+}
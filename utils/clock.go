@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"time"
+
+	"twitchgo/types"
+)
+
+// SystemClock is the wall clock.
+var SystemClock types.Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) types.Timer {
+	return time.AfterFunc(d, f)
+}
@@ -3,22 +3,36 @@ package utils
 import (
 	"sync"
 	"time"
+
+	"twitchgo/types"
 )
 
-var cooldowns = make(map[string]time.Time)
-var mu sync.Mutex
+type Cooldowns struct {
+	mutex sync.Mutex
+	until map[string]time.Time
+	clock types.Clock
+}
+
+func NewCooldowns(clock types.Clock) *Cooldowns {
+	return &Cooldowns{
+		until: make(map[string]time.Time),
+		clock: clock,
+	}
+}
 
-func IsOnCooldown(user, cmd string, duration time.Duration) bool {
-	mu.Lock()
-	defer mu.Unlock()
+// IsOnCooldown reports whether cmd is still cooling down for user. When it
+// isn't, a new cooldown of duration starts.
+func (c *Cooldowns) IsOnCooldown(user, cmd string, duration time.Duration) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
 	key := user + ":" + cmd
-	now := time.Now()
+	now := c.clock.Now()
 
-	if t, exists := cooldowns[key]; exists && now.Before(t) {
+	if t, exists := c.until[key]; exists && now.Before(t) {
 		return true
 	}
 
-	cooldowns[key] = now.Add(duration)
+	c.until[key] = now.Add(duration)
 	return false
 }
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"time"
+
+	"twitchgo/types"
+)
+
+const day = 24 * time.Hour
+
+// DailyPeriod returns the index of the claim period containing t and the
+// time the following period starts. Periods that are whole days follow the
+// calendar of the configured location, so DST changes don't shift the reset.
+func DailyPeriod(t time.Time, config types.DailyConfig) (int64, time.Time) {
+	loc := config.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	period := config.Period
+	if period <= 0 {
+		period = day
+	}
+
+	local := t.In(loc)
+	reset := time.Duration(config.ResetHour) * time.Hour
+
+	if period%day == 0 {
+		days := int64(period / day)
+		y, m, d := local.Add(-reset).Date()
+		dayNumber := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / int64(day/time.Second)
+		index := floorDiv(dayNumber, days)
+
+		next := time.Unix((index+1)*days*int64(day/time.Second), 0).UTC()
+		return index, time.Date(next.Year(), next.Month(), next.Day(), config.ResetHour, 0, 0, 0, loc)
+	}
+
+	anchor := time.Date(2000, time.January, 1, config.ResetHour, 0, 0, 0, loc)
+	index := floorDiv(int64(t.Sub(anchor)), int64(period))
+	return index, anchor.Add(time.Duration(index+1) * period)
+}
+
+func DailyStreakBonus(streak int, config types.DailyConfig) int {
+	bonus := (streak - 1) * config.StreakBonus
+	if bonus < 0 {
+		return 0
+	}
+	if config.MaxStreakBonus > 0 && bonus > config.MaxStreakBonus {
+		return config.MaxStreakBonus
+	}
+	return bonus
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
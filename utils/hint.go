@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"unicode"
+)
+
+// GenerateHint masks the letters of answer with underscores, leaving the
+// share reveal (0 to 1) of them visible. Spaces and punctuation always show
+// and at least one letter stays hidden. For a given answer, the letters shown
+// at a smaller share are always among those shown at a larger one, so each
+// hint only adds letters to the last.
+func GenerateHint(answer string, reveal float64) string {
+	runes := []rune(answer)
+
+	var letters []int
+	for i, r := range runes {
+		if isWordLetter(r) {
+			letters = append(letters, i)
+		}
+	}
+
+	count := int(math.Round(float64(len(letters)) * reveal))
+	if count >= len(letters) {
+		count = len(letters) - 1
+	}
+	if count < 0 {
+		count = 0
+	}
+
+	shown := make(map[int]bool, count)
+	for _, i := range hintOrder(runes, letters)[:count] {
+		shown[i] = true
+	}
+
+	hint := make([]rune, len(runes))
+	for i, r := range runes {
+		if isWordLetter(r) && !shown[i] {
+			hint[i] = '_'
+		} else {
+			hint[i] = r
+		}
+	}
+	return string(hint)
+}
+
+// hintOrder lists the letter positions in the order they get revealed: the
+// first letter of each word, then the rest in an order fixed by the answer.
+func hintOrder(runes []rune, letters []int) []int {
+	var initials, rest []int
+	for _, i := range letters {
+		if i == 0 || !isWordLetter(runes[i-1]) {
+			initials = append(initials, i)
+		} else {
+			rest = append(rest, i)
+		}
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(string(runes)))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+	rng.Shuffle(len(rest), func(i, j int) {
+		rest[i], rest[j] = rest[j], rest[i]
+	})
+
+	return append(initials, rest...)
+}
+
+func isWordLetter(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
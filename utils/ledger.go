@@ -0,0 +1,191 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"twitchgo/types"
+)
+
+// FileLedger is an append-only JSON lines log of balance changes. Every
+// append is fsynced before it returns.
+type FileLedger struct {
+	mutex   sync.RWMutex
+	file    *os.File
+	path    string
+	entries map[string][]types.LedgerEntry
+	nextID  int64
+}
+
+func OpenFileLedger(path string) (*FileLedger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+
+	ledger := &FileLedger{
+		path:    path,
+		entries: make(map[string][]types.LedgerEntry),
+		nextID:  1,
+	}
+
+	intact, unterminated, err := ledger.load()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	ledger.file = file
+
+	if err := file.Truncate(intact); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to drop torn ledger line: %w", err)
+	}
+	if unterminated {
+		if _, err := file.Write([]byte("\n")); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to terminate ledger line: %w", err)
+		}
+	}
+
+	return ledger, nil
+}
+
+func (l *FileLedger) Append(entries ...types.LedgerEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var buf []byte
+	for i := range entries {
+		entries[i].ID = l.nextID + int64(i)
+		line, err := json.Marshal(entries[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode ledger entry: %w", err)
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	if _, err := l.file.Write(buf); err != nil {
+		return fmt.Errorf("failed to append to ledger: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync ledger: %w", err)
+	}
+
+	for _, entry := range entries {
+		l.entries[entry.Username] = append(l.entries[entry.Username], entry)
+	}
+	l.nextID += int64(len(entries))
+	return nil
+}
+
+// History returns the newest entries for username, newest first.
+func (l *FileLedger) History(username string, limit int) []types.LedgerEntry {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	entries := l.entries[username]
+	var history []types.LedgerEntry
+	for i := len(entries) - 1; i >= 0 && len(history) < limit; i-- {
+		history = append(history, entries[i])
+	}
+	return history
+}
+
+// Replay sums every delta recorded for username.
+func (l *FileLedger) Replay(username string) (balance int, count int) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	for _, entry := range l.entries[username] {
+		balance += entry.Delta
+	}
+	return balance, len(l.entries[username])
+}
+
+// Since returns the entries for username with reason recorded after t,
+// oldest first.
+func (l *FileLedger) Since(username string, reason types.Reason, t time.Time) []types.LedgerEntry {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	var entries []types.LedgerEntry
+	for _, entry := range l.entries[username] {
+		if entry.Reason == reason && entry.Time.After(t) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (l *FileLedger) Users() []string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	users := make([]string, 0, len(l.entries))
+	for username := range l.entries {
+		users = append(users, username)
+	}
+	return users
+}
+
+func (l *FileLedger) Close() error {
+	return l.file.Close()
+}
+
+// load reads the entries in the ledger file. It returns how many bytes of
+// the file are intact and whether they lack a final newline.
+func (l *FileLedger) load() (int64, bool, error) {
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read ledger: %w", err)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	lastLine := len(lines) - 1
+	for lastLine >= 0 && len(lines[lastLine]) == 0 {
+		lastLine--
+	}
+
+	var offset int64
+	count := 0
+	for i, line := range lines {
+		start := offset
+		offset += int64(len(line)) + 1
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry types.LedgerEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			// A crash can leave a torn final line; everything before it is
+			// intact. Anything else is damage that replaying would hide.
+			if i < lastLine {
+				return 0, false, fmt.Errorf("ledger line %d is corrupt: %w", i+1, err)
+			}
+			log.Printf("Dropping torn ledger line %d: %v", i+1, err)
+			log.Printf("Loaded %d ledger entries from %s", count, l.path)
+			return start, false, nil
+		}
+		l.entries[entry.Username] = append(l.entries[entry.Username], entry)
+		if entry.ID >= l.nextID {
+			l.nextID = entry.ID + 1
+		}
+		count++
+	}
+
+	log.Printf("Loaded %d ledger entries from %s", count, l.path)
+	return int64(len(data)), len(data) > 0 && data[len(data)-1] != '\n', nil
+}
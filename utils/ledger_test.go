@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"twitchgo/types"
+)
+
+func writeLedger(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ledger.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const (
+	ledgerLine1 = `{"id":1,"time":"2025-01-01T12:00:00Z","username":"viewer","delta":100,"balance":100,"reason":"admin"}` + "\n"
+	ledgerLine2 = `{"id":2,"time":"2025-01-01T12:01:00Z","username":"viewer","delta":-30,"balance":70,"reason":"gamble"}` + "\n"
+)
+
+func TestLedgerRejectsCorruptMiddleLine(t *testing.T) {
+	path := writeLedger(t, ledgerLine1, `{"id":2,"user`+"\n", ledgerLine2)
+
+	if _, err := OpenFileLedger(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("OpenFileLedger error = %v, want line 2 reported as corrupt", err)
+	}
+	dir := filepath.Dir(path)
+	if _, err := NewInMemoryPointsDB(filepath.Join(dir, "user_data.json"), path, 0); err == nil {
+		t.Fatal("points database opened over a corrupt ledger")
+	}
+}
+
+func TestLedgerDropsTornFinalLine(t *testing.T) {
+	tests := []struct {
+		name string
+		torn string
+	}{
+		{"unterminated", `{"id":3,"username":"vie`},
+		// Older versions terminated the torn line before appending.
+		{"terminated", `{"id":3,"username":"vie` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLedger(t, ledgerLine1, ledgerLine2, tt.torn)
+
+			ledger, err := OpenFileLedger(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance, count := ledger.Replay("viewer"); balance != 70 || count != 2 {
+				t.Fatalf("Replay = %d over %d entries, want 70 over 2", balance, count)
+			}
+			err = ledger.Append(types.LedgerEntry{Time: time.Now(), Username: "viewer", Delta: 5, Balance: 75, Reason: types.ReasonAdmin})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ledger.Close()
+
+			// The torn line is gone, so the ledger reopens cleanly.
+			ledger, err = OpenFileLedger(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ledger.Close()
+			if balance, count := ledger.Replay("viewer"); balance != 75 || count != 3 {
+				t.Fatalf("Replay after reopening = %d over %d entries, want 75 over 3", balance, count)
+			}
+		})
+	}
+}
+
+func TestLedgerKeepsUnterminatedEntry(t *testing.T) {
+	path := writeLedger(t, ledgerLine1, strings.TrimSuffix(ledgerLine2, "\n"))
+
+	ledger, err := OpenFileLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ledger.Append(types.LedgerEntry{Time: time.Now(), Username: "viewer", Delta: 5, Balance: 75, Reason: types.ReasonAdmin}); err != nil {
+		t.Fatal(err)
+	}
+	ledger.Close()
+
+	ledger, err = OpenFileLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ledger.Close()
+	if balance, count := ledger.Replay("viewer"); balance != 75 || count != 3 {
+		t.Fatalf("Replay = %d over %d entries, want 75 over 3", balance, count)
+	}
+}
@@ -0,0 +1,12 @@
+package utils_test
+
+import (
+	"testing"
+
+	"twitchgo/internal/testenv"
+)
+
+// Every balance change is logged; the stress tests make thousands.
+func TestMain(m *testing.M) {
+	testenv.Main(m)
+}
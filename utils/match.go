@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"twitchgo/types"
+)
+
+// MatchConfig holds the similarity thresholds used to judge a guess.
+type MatchConfig struct {
+	// Accept is the similarity at which a guess counts as correct.
+	Accept float64 `yaml:"accept"`
+	// Close is the similarity at which a wrong guess is called close.
+	Close float64 `yaml:"close"`
+}
+
+func DefaultTriviaMatchConfig() MatchConfig {
+	return MatchConfig{Accept: 0.82, Close: 0.6}
+}
+
+func DefaultScrambleMatchConfig() MatchConfig {
+	return MatchConfig{Accept: 0.85, Close: 0.75}
+}
+
+// CheckTriviaGuess checks guess against every pattern and accepted answer of
+// question. A pattern match counts as exact; otherwise the best scoring
+// answer decides.
+func (c MatchConfig) CheckTriviaGuess(guess string, question types.TriviaQuestion) (bool, float64) {
+	sanitized := SanitizeMessage(guess)
+	for _, pattern := range question.Patterns {
+		if re := CompileAnswerPattern(pattern); re != nil && re.MatchString(sanitized) {
+			return true, 1.0
+		}
+	}
+
+	best := 0.0
+	for _, answer := range question.AcceptedAnswers() {
+		if similarity := CalculateSimilarity(guess, answer); similarity > best {
+			best = similarity
+		}
+	}
+
+	return best >= c.Accept, best
+}
+
+func (c MatchConfig) CheckScrambleGuess(guess, word string) (bool, float64) {
+	similarity := CalculateSimilarity(guess, word)
+	return similarity >= c.Accept, similarity
+}
+
+// CalculateSimilarity scores guess against answer from 0 to 1. Both are
+// normalized first (case, accents, punctuation and a leading article), then
+// compared as whole strings and word by word, keeping the better score.
+// Extra words in the guess lower the score.
+func CalculateSimilarity(guess, answer string) float64 {
+	guessTokens := AnswerTokens(guess)
+	answerTokens := AnswerTokens(answer)
+
+	if len(guessTokens) == 0 || len(answerTokens) == 0 {
+		if len(guessTokens) == len(answerTokens) {
+			return 1.0
+		}
+		return 0.0
+	}
+
+	whole := runeSimilarity([]rune(strings.Join(guessTokens, " ")), []rune(strings.Join(answerTokens, " ")))
+	tokens := tokenSimilarity(guessTokens, answerTokens)
+	if tokens > whole {
+		return tokens
+	}
+	return whole
+}
+
+// leadingArticles are dropped from the start of answers and guesses, so
+// "o gaules" and "gaules" match.
+var leadingArticles = map[string]bool{
+	"o": true, "a": true, "os": true, "as": true,
+	"um": true, "uma": true, "uns": true, "umas": true,
+	"the": true,
+}
+
+// AnswerTokens lowercases s, strips accents, turns punctuation into spaces
+// and splits it into words, dropping a leading article.
+func AnswerTokens(s string) []string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		r = foldAccent(r)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+
+	tokens := strings.Fields(b.String())
+	if len(tokens) > 1 && leadingArticles[tokens[0]] {
+		tokens = tokens[1:]
+	}
+	return tokens
+}
+
+// NormalizeAnswer is AnswerTokens joined back into one string.
+func NormalizeAnswer(s string) string {
+	return strings.Join(AnswerTokens(s), " ")
+}
+
+var accentFolds = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n', 'ý': 'y', 'ÿ': 'y',
+}
+
+func foldAccent(r rune) rune {
+	if folded, ok := accentFolds[r]; ok {
+		return folded
+	}
+	return r
+}
+
+// tokenSimilarity pairs each answer word with its closest unused guess word,
+// so word order doesn't matter, and weighs the pairs by length.
+func tokenSimilarity(guess, answer []string) float64 {
+	used := make([]bool, len(guess))
+	matched := 0.0
+	answerLen, guessLen := 0, 0
+
+	for _, word := range guess {
+		guessLen += len([]rune(word))
+	}
+
+	for _, word := range answer {
+		runes := []rune(word)
+		answerLen += len(runes)
+
+		best, bestIndex := 0.0, -1
+		for i, candidate := range guess {
+			if used[i] {
+				continue
+			}
+			if similarity := runeSimilarity([]rune(candidate), runes); similarity > best {
+				best, bestIndex = similarity, i
+			}
+		}
+
+		if bestIndex >= 0 {
+			used[bestIndex] = true
+			matched += best * float64(len(runes))
+		}
+	}
+
+	total := answerLen
+	if guessLen > total {
+		total = guessLen
+	}
+	return matched / float64(total)
+}
+
+// runeSimilarity is one minus the edit distance relative to the longer
+// string.
+func runeSimilarity(a, b []rune) float64 {
+	longest := len(a)
+	if len(b) > longest {
+		longest = len(b)
+	}
+	if longest == 0 {
+		return 1.0
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+// Levenshtein counts the single-rune insertions, deletions and substitutions
+// that turn a into b.
+func Levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
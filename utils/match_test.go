@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"twitchgo/types"
+)
+
+func TestAnswerTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"Júlia", []string{"julia"}},
+		{"  O Gaules!! ", []string{"gaules"}},
+		{"The Office", []string{"office"}},
+		{"pão-de-ló", []string{"pao", "de", "lo"}},
+		// A lone article is the answer, not a prefix.
+		{"a", []string{"a"}},
+		{"Ação 2", []string{"acao", "2"}},
+		{"", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := AnswerTokens(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AnswerTokens(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateSimilarity(t *testing.T) {
+	tests := []struct {
+		name          string
+		guess, answer string
+		min, max      float64
+	}{
+		{"identical", "Casimiro", "Casimiro", 1, 1},
+		{"case", "CASIMIRO", "casimiro", 1, 1},
+		{"accents", "julia", "Júlia", 1, 1},
+		{"accents in guess", "kéfera", "Kefera", 1, 1},
+		{"cedilla and tilde", "acao", "Ação", 1, 1},
+		{"punctuation", "jojo todynho!!!", "Jojo Todynho", 1, 1},
+		{"leading article in answer", "gaules", "O Gaules", 1, 1},
+		{"leading article in guess", "a carminha", "Carminha", 1, 1},
+		{"word order", "todynho jojo", "Jojo Todynho", 1, 1},
+		{"word order, three words", "destino senhora do", "Senhora do Destino", 1, 1},
+		{"one typo", "casimro", "Casimiro", 0.85, 0.9},
+		{"extra word", "manoel gomes cantor", "Manoel Gomes", 0.6, 0.8},
+		{"many extra words", "acho que e o manoel gomes", "Manoel Gomes", 0, 0.6},
+		{"missing word", "manoel", "Manoel Gomes", 0.4, 0.6},
+		{"unrelated", "podpah", "Casimiro", 0, 0.3},
+		{"multi-byte runes count once", "júlía", "julia", 1, 1},
+		{"empty guess", "", "Casimiro", 0, 0},
+		{"both empty", "!!", "", 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateSimilarity(tt.guess, tt.answer)
+			if got < tt.min || got > tt.max {
+				t.Errorf("CalculateSimilarity(%q, %q) = %.3f, want between %.2f and %.2f",
+					tt.guess, tt.answer, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"júlia", "julia", 1},
+		{"flaw", "lawn", 2},
+	}
+
+	for _, tt := range tests {
+		if got := Levenshtein([]rune(tt.a), []rune(tt.b)); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchConfigThresholds(t *testing.T) {
+	config := MatchConfig{Accept: 0.8, Close: 0.6}
+
+	tests := []struct {
+		guess, word string
+		correct     bool
+		close       bool
+	}{
+		{"casimiro", "Casimiro", true, false},
+		// 1 - 1/8 = 0.875 clears Accept.
+		{"casimro", "Casimiro", true, false},
+		// 1 - 2/8 = 0.75 is close but not correct.
+		{"casimr", "Casimiro", false, true},
+		// 1 - 3/8 = 0.625 is still close.
+		{"casim", "Casimiro", false, true},
+		// 1 - 4/8 = 0.5 is neither.
+		{"casi", "Casimiro", false, false},
+	}
+
+	for _, tt := range tests {
+		correct, similarity := config.CheckScrambleGuess(tt.guess, tt.word)
+		if correct != tt.correct {
+			t.Errorf("CheckScrambleGuess(%q, %q) correct = %v (%.3f), want %v",
+				tt.guess, tt.word, correct, similarity, tt.correct)
+		}
+		if close := !correct && similarity >= config.Close; close != tt.close {
+			t.Errorf("CheckScrambleGuess(%q, %q) close = %v (%.3f), want %v",
+				tt.guess, tt.word, close, similarity, tt.close)
+		}
+	}
+}
+
+func TestMatchConfigBoundaries(t *testing.T) {
+	// Exactly at a threshold counts.
+	exact := MatchConfig{Accept: 0.875, Close: 0.75}
+	if correct, _ := exact.CheckScrambleGuess("casimro", "Casimiro"); !correct {
+		t.Error("guess at exactly Accept was rejected")
+	}
+	if correct, similarity := exact.CheckScrambleGuess("casimr", "Casimiro"); correct || similarity < exact.Close {
+		t.Errorf("guess at exactly Close: correct = %v, similarity = %.3f", correct, similarity)
+	}
+
+	strict := MatchConfig{Accept: 1, Close: 1}
+	if correct, _ := strict.CheckScrambleGuess("casimro", "Casimiro"); correct {
+		t.Error("strict config accepted a typo")
+	}
+	if correct, _ := strict.CheckScrambleGuess("CASIMIRO", "Casimiro"); !correct {
+		t.Error("strict config rejected a case difference")
+	}
+}
+
+func TestCheckTriviaGuess(t *testing.T) {
+	question := types.TriviaQuestion{
+		Answer:   "Big Brother Brasil",
+		Answers:  []string{"BBB"},
+		Patterns: []string{`^big brother( brasil)?$`},
+	}
+	config := DefaultTriviaMatchConfig()
+
+	tests := []struct {
+		guess   string
+		correct bool
+	}{
+		{"big brother brasil", true},
+		{"Big Brother", true},
+		{"bbb", true},
+		{"brasil big brother", true},
+		{"big brothr brasil", true},
+		{"bb", false},
+		{"a fazenda", false},
+	}
+
+	for _, tt := range tests {
+		if correct, similarity := config.CheckTriviaGuess(tt.guess, question); correct != tt.correct {
+			t.Errorf("CheckTriviaGuess(%q) = %v (%.3f), want %v", tt.guess, correct, similarity, tt.correct)
+		}
+	}
+}
@@ -15,40 +15,133 @@ import (
 )
 
 type InMemoryPointsDB struct {
-	users map[string]*types.UserData
-	mutex sync.RWMutex
-	rng   *rand.Rand
-	path  string
+	users   map[string]*types.UserData
+	mutex   sync.RWMutex
+	rng     *rand.Rand
+	path    string
+	backups int
+	ledger  *FileLedger
 }
 
-func NewInMemoryPointsDB() *InMemoryPointsDB {
+const (
+	PointsBackendJSON   = "json"
+	PointsBackendSQLite = "sqlite"
+)
+
+type PointsConfig struct {
+	Backend    string `yaml:"backend"`
+	JSONPath   string `yaml:"json_path"`
+	SQLitePath string `yaml:"sqlite_path"`
+	LedgerPath string `yaml:"ledger_path"`
+	Backups    int    `yaml:"backups"`
+}
+
+func DefaultPointsConfig() PointsConfig {
+	return PointsConfig{
+		Backend:    PointsBackendJSON,
+		JSONPath:   filepath.Join("data", "user_data.json"),
+		SQLitePath: filepath.Join("data", "points.db"),
+		LedgerPath: filepath.Join("data", "ledger.jsonl"),
+		Backups:    5,
+	}
+}
+
+// ForChannel moves every data file into a directory named after channel.
+func (c PointsConfig) ForChannel(channel string) PointsConfig {
+	c.JSONPath = channelPath(c.JSONPath, channel)
+	c.SQLitePath = channelPath(c.SQLitePath, channel)
+	c.LedgerPath = channelPath(c.LedgerPath, channel)
+	return c
+}
+
+// MigrateLegacyPointsData moves the data files of a single-channel install
+// to the paths of target. Files that already exist at target are left alone.
+func MigrateLegacyPointsData(legacy, target PointsConfig) error {
+	moves := map[string]string{
+		legacy.JSONPath:            target.JSONPath,
+		legacy.LedgerPath:          target.LedgerPath,
+		legacy.SQLitePath:          target.SQLitePath,
+		legacy.SQLitePath + "-wal": target.SQLitePath + "-wal",
+		legacy.SQLitePath + "-shm": target.SQLitePath + "-shm",
+	}
+	for i := 1; i <= legacy.Backups; i++ {
+		moves[backupName(legacy.JSONPath, i)] = backupName(target.JSONPath, i)
+	}
+
+	for from, to := range moves {
+		if from == to {
+			continue
+		}
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if _, err := os.Stat(to); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(to), err)
+		}
+		if err := os.Rename(from, to); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", from, to, err)
+		}
+		log.Printf("Moved legacy data file %s to %s", from, to)
+	}
+
+	return nil
+}
+
+func channelPath(path, channel string) string {
+	return filepath.Join(filepath.Dir(path), channel, filepath.Base(path))
+}
+
+func NewPointsDatabase(config PointsConfig) (types.PointsDatabase, error) {
+	switch strings.ToLower(config.Backend) {
+	case "", PointsBackendJSON:
+		return NewInMemoryPointsDB(config.JSONPath, config.LedgerPath, config.Backups)
+	case PointsBackendSQLite:
+		return NewSQLitePointsDB(config.SQLitePath, config.JSONPath)
+	default:
+		return nil, fmt.Errorf("unknown points backend %q", config.Backend)
+	}
+}
+
+func NewInMemoryPointsDB(path, ledgerPath string, backups int) (*InMemoryPointsDB, error) {
 	db := &InMemoryPointsDB{
-		users: make(map[string]*types.UserData),
-		rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
-		path:  filepath.Join("data", "user_data.json"),
+		users:   make(map[string]*types.UserData),
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		path:    path,
+		backups: backups,
 	}
 
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("Failed to create data directory: %v", err)
+	}
+
+	ledger, err := OpenFileLedger(ledgerPath)
+	if err != nil {
+		return nil, err
+	}
+	db.ledger = ledger
+
 	if err := db.LoadFromFile(); err != nil {
 		log.Printf("Failed to load user data: %v", err)
 		log.Println("Starting with empty user database")
 	}
 
-	return db
+	if err := db.reconcileLedger(); err != nil {
+		ledger.Close()
+		return nil, err
+	}
+
+	return db, nil
 }
 
 func (db *InMemoryPointsDB) ValidateUser(username string) error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
-	username = strings.ToLower(username)
-	if _, exists := db.users[username]; !exists {
-		db.users[username] = &types.UserData{
-			Username:   username,
-			Points:     0,
-			GambleLoss: 0,
-		}
-		log.Printf("Created new user: %s", username)
-	}
+	db.user(username)
 	return nil
 }
 
@@ -63,42 +156,20 @@ func (db *InMemoryPointsDB) GetPoints(username string) int {
 	return 0
 }
 
-func (db *InMemoryPointsDB) AddPoints(username string, amount int) error {
+func (db *InMemoryPointsDB) AddPoints(username string, amount int, reason types.Reason) error {
 	if amount < 0 {
 		return fmt.Errorf("cannot add negative points")
 	}
 
-	db.ValidateUser(username)
-	db.mutex.Lock()
-	defer db.mutex.Unlock()
-
-	username = strings.ToLower(username)
-	db.users[username].Points += amount
-	log.Printf("Added %d points to %s (new balance: %d)", amount, username, db.users[username].Points)
-	return nil
+	return db.adjust(username, amount, reason, "")
 }
 
-func (db *InMemoryPointsDB) SubtractPoints(username string, amount int) error {
+func (db *InMemoryPointsDB) SubtractPoints(username string, amount int, reason types.Reason) error {
 	if amount < 0 {
 		return fmt.Errorf("cannot subtract negative points")
 	}
 
-	db.ValidateUser(username)
-	db.mutex.Lock()
-	defer db.mutex.Unlock()
-
-	username = strings.ToLower(username)
-	user := db.users[username]
-
-	newBalance := user.Points - amount
-	if newBalance < 0 {
-		newBalance = 0
-		log.Printf("Warning: %s would have negative points, setting to 0", username)
-	}
-
-	user.Points = newBalance
-	log.Printf("Subtracted %d points from %s (new balance: %d)", amount, username, user.Points)
-	return nil
+	return db.adjust(username, -amount, reason, "")
 }
 
 func (db *InMemoryPointsDB) GetGambleLoss(username string) int {
@@ -136,90 +207,119 @@ func (db *InMemoryPointsDB) TransferPoints(sender, receiver string, amount int)
 	receiver = strings.ToLower(receiver)
 
 	if sender == receiver {
-		return fmt.Errorf("cannot transfer to yourself")
+		return types.ErrSelfTransfer
 	}
 
-	db.ValidateUser(sender)
-	db.ValidateUser(receiver)
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	from := db.user(sender)
+	to := db.user(receiver)
 
-	senderPoints := db.GetPoints(sender)
-	if senderPoints < amount {
-		return fmt.Errorf("insufficient points")
+	if from.Points < amount {
+		return types.ErrInsufficientPoints
 	}
 
-	if err := db.SubtractPoints(sender, amount); err != nil {
-		return err
-	}
-	if err := db.AddPoints(receiver, amount); err != nil {
-		// Revert sender subtraction
-		db.AddPoints(sender, amount)
+	now := time.Now()
+	err := db.ledger.Append(
+		types.LedgerEntry{Time: now, Username: sender, Delta: -amount, Balance: from.Points - amount, Reason: types.ReasonTransfer, Counterparty: receiver},
+		types.LedgerEntry{Time: now, Username: receiver, Delta: amount, Balance: to.Points + amount, Reason: types.ReasonTransfer, Counterparty: sender},
+	)
+	if err != nil {
 		return err
 	}
 
+	from.Points -= amount
+	to.Points += amount
+
 	log.Printf("Transferred %d points from %s to %s", amount, sender, receiver)
 	return nil
 }
 
 func (db *InMemoryPointsDB) Gamble(username string, wager int, format string, winOdds float64) (string, int, int, error) {
-	if winOdds < 0 || winOdds > 1 {
-		return "", 0, 0, fmt.Errorf("win odds must be between 0 and 1")
-	}
-
-	if format != "points" && format != "percent" && format != "all" {
-		return "", 0, 0, fmt.Errorf("format must be 'points', 'percent', or 'all'")
-	}
-
-	db.ValidateUser(username)
-	currentPoints := db.GetPoints(username)
-
-	if currentPoints == 0 {
-		return "no points", currentPoints, 0, nil
+	if err := validateGamble(wager, format, winOdds); err != nil {
+		return "", 0, 0, err
 	}
 
-	var actualWager int
-
-	switch format {
-	case "points":
-		if currentPoints < wager {
-			return "not enough points", currentPoints, 0, nil
-		}
-		actualWager = wager
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
 
-	case "percent":
-		if wager > 100 {
-			return "invalid percent", currentPoints, 0, nil
-		}
-		actualWager = int(float64(currentPoints) * float64(wager) / 100.0)
-		if actualWager == 0 {
-			return "not enough points", currentPoints, 0, nil
-		}
-		if currentPoints < actualWager {
-			return "not enough points", currentPoints, 0, nil
-		}
+	user := db.user(username)
+	currentPoints := user.Points
 
-	case "all":
-		actualWager = currentPoints
+	actualWager, rejection := resolveWager(currentPoints, wager, format)
+	if rejection != "" {
+		return rejection, currentPoints, 0, nil
 	}
 
 	// Determine outcome
 	outcome := "lose"
+	delta := -actualWager
 	if db.rng.Float64() < winOdds {
 		outcome = "win"
+		delta = actualWager
+	}
+
+	if err := db.record(user, delta, types.ReasonGamble, "", time.Now()); err != nil {
+		return "", 0, 0, err
 	}
 
-	var newBalance int
+	user.Points += delta
 	if outcome == "win" {
-		db.AddPoints(username, actualWager)
-		newBalance = currentPoints + actualWager
-		log.Printf("Gamble result: %s won %d points (balance: %d -> %d)", username, actualWager, currentPoints, newBalance)
-		return "win", newBalance, actualWager, nil
+		log.Printf("Gamble result: %s won %d points (balance: %d -> %d)", user.Username, actualWager, currentPoints, user.Points)
 	} else {
-		db.SubtractPoints(username, actualWager)
-		db.AddGambleLoss(username, actualWager)
-		newBalance = currentPoints - actualWager
-		log.Printf("Gamble result: %s lost %d points (balance: %d -> %d)", username, actualWager, currentPoints, newBalance)
-		return "lose", newBalance, actualWager, nil
+		user.GambleLoss += actualWager
+		log.Printf("Gamble result: %s lost %d points (balance: %d -> %d)", user.Username, actualWager, currentPoints, user.Points)
 	}
+
+	return outcome, user.Points, actualWager, nil
+}
+
+func (db *InMemoryPointsDB) ClaimDaily(username string, now time.Time, config types.DailyConfig) (types.DailyClaim, error) {
+	if config.Amount < 0 {
+		return types.DailyClaim{}, fmt.Errorf("daily amount must not be negative")
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	user := db.user(username)
+	updated := *user
+	claim := applyDailyClaim(&updated, now, config)
+	if !claim.Claimed {
+		return claim, nil
+	}
+
+	// The streak goes in the ledger too, since a snapshot older than the
+	// claim would otherwise forget it.
+	if err := db.ledger.Append(types.LedgerEntry{
+		Time:     now,
+		Username: user.Username,
+		Delta:    claim.Amount + claim.Bonus,
+		Balance:  updated.Points,
+		Reason:   types.ReasonDaily,
+		Streak:   claim.Streak,
+	}); err != nil {
+		return types.DailyClaim{}, err
+	}
+	*user = updated
+	return claim, nil
+}
+
+func (db *InMemoryPointsDB) GetHistory(username string, limit int) []types.LedgerEntry {
+	return db.ledger.History(strings.ToLower(username), limit)
+}
+
+func (db *InMemoryPointsDB) AuditUser(username string) (types.LedgerAudit, error) {
+	username = strings.ToLower(username)
+	replayed, entries := db.ledger.Replay(username)
+
+	return types.LedgerAudit{
+		Username: username,
+		Entries:  entries,
+		Replayed: replayed,
+		Balance:  db.GetPoints(username),
+	}, nil
 }
 
 func (db *InMemoryPointsDB) GetTopPoints(limit int) ([]string, []int) {
@@ -317,10 +417,16 @@ func (db *InMemoryPointsDB) GetRank(username string) (int, int) {
 		users = append(users, userScore{user.Username, user.Points, user.GambleLoss})
 	}
 
+	// A user with no entry yet ranks as if they had 0 points and no losses.
+	var points, gambleLoss int
+	if u, ok := db.users[username]; ok {
+		points, gambleLoss = u.Points, u.GambleLoss
+	}
+
 	// Sort by points (descending)
 	pointsRank := 1
 	for _, user := range users {
-		if user.points > db.users[username].Points {
+		if user.points > points {
 			pointsRank++
 		}
 	}
@@ -328,7 +434,7 @@ func (db *InMemoryPointsDB) GetRank(username string) (int, int) {
 	// Sort by gamble loss (descending)
 	lossRank := 1
 	for _, user := range users {
-		if user.gambleLoss > db.users[username].GambleLoss {
+		if user.gambleLoss > gambleLoss {
 			lossRank++
 		}
 	}
@@ -345,16 +451,13 @@ func (db *InMemoryPointsDB) SaveToFile() error {
 		users = append(users, *user)
 	}
 
-	file, err := os.Create(db.path)
+	data, err := json.MarshalIndent(users, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to create user data file: %w", err)
+		return fmt.Errorf("failed to encode user data: %w", err)
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(users); err != nil {
-		return fmt.Errorf("failed to encode user data: %w", err)
+	if err := WriteFileAtomic(db.path, data, db.backups); err != nil {
+		return fmt.Errorf("failed to write user data file: %w", err)
 	}
 
 	log.Printf("Successfully saved %d users to %s", len(users), db.path)
@@ -362,16 +465,17 @@ func (db *InMemoryPointsDB) SaveToFile() error {
 }
 
 func (db *InMemoryPointsDB) LoadFromFile() error {
-	file, err := os.Open(db.path)
+	var users []types.UserData
+	_, source, err := ReadFileWithBackups(db.path, db.backups, func(data []byte) error {
+		users = nil
+		return json.Unmarshal(data, &users)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to open user data file: %w", err)
+		return fmt.Errorf("failed to read user data file: %w", err)
 	}
-	defer file.Close()
 
-	var users []types.UserData
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&users); err != nil {
-		return fmt.Errorf("failed to decode user data: %w", err)
+	if source != db.path {
+		log.Printf("User data file %s is missing or corrupt, restored from backup %s", db.path, source)
 	}
 
 	db.mutex.Lock()
@@ -379,13 +483,195 @@ func (db *InMemoryPointsDB) LoadFromFile() error {
 
 	db.users = make(map[string]*types.UserData)
 	for _, user := range users {
-		db.users[strings.ToLower(user.Username)] = &types.UserData{
-			Username:   user.Username,
-			Points:     user.Points,
-			GambleLoss: user.GambleLoss,
+		user := user
+		db.users[strings.ToLower(user.Username)] = &user
+	}
+
+	log.Printf("Successfully loaded %d users from %s", len(users), source)
+	return nil
+}
+
+func (db *InMemoryPointsDB) Close() error {
+	return db.ledger.Close()
+}
+
+// user returns the record for username, creating it if needed. The caller
+// must hold the write lock.
+func (db *InMemoryPointsDB) user(username string) *types.UserData {
+	username = strings.ToLower(username)
+	user, exists := db.users[username]
+	if !exists {
+		user = &types.UserData{Username: username}
+		db.users[username] = user
+		log.Printf("Created new user: %s", username)
+	}
+	return user
+}
+
+func (db *InMemoryPointsDB) adjust(username string, delta int, reason types.Reason, counterparty string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	user := db.user(username)
+	if user.Points+delta < 0 {
+		log.Printf("Warning: %s would have negative points, setting to 0", user.Username)
+		delta = -user.Points
+	}
+
+	if err := db.record(user, delta, reason, counterparty, time.Now()); err != nil {
+		return err
+	}
+
+	user.Points += delta
+	if delta >= 0 {
+		log.Printf("Added %d points to %s (new balance: %d)", delta, user.Username, user.Points)
+	} else {
+		log.Printf("Subtracted %d points from %s (new balance: %d)", -delta, user.Username, user.Points)
+	}
+	return nil
+}
+
+// record appends a balance change for user to the ledger before it is
+// applied. The caller must hold the write lock.
+func (db *InMemoryPointsDB) record(user *types.UserData, delta int, reason types.Reason, counterparty string, now time.Time) error {
+	return db.ledger.Append(types.LedgerEntry{
+		Time:         now,
+		Username:     user.Username,
+		Delta:        delta,
+		Balance:      user.Points + delta,
+		Reason:       reason,
+		Counterparty: counterparty,
+	})
+}
+
+// reconcileLedger makes the loaded balances agree with the ledger. Balances
+// the ledger has never seen get an opening entry; balances and daily claims
+// that drifted because the snapshot is older than the ledger are restored
+// from it.
+func (db *InMemoryPointsDB) reconcileLedger() error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	for _, username := range db.ledger.Users() {
+		replayed, _ := db.ledger.Replay(username)
+		user := db.user(username)
+		if user.Points != replayed {
+			log.Printf("Restoring %s balance from ledger (%d -> %d)", username, user.Points, replayed)
+			user.Points = replayed
 		}
+
+		// Daily claims the snapshot missed would otherwise be claimable
+		// again. Entries written before streaks were recorded restart it.
+		if missed := db.ledger.Since(username, types.ReasonDaily, user.LastDaily); len(missed) > 0 {
+			last := missed[len(missed)-1]
+			log.Printf("Restoring %s last daily claim from ledger (%s -> %s)", username, user.LastDaily, last.Time)
+			user.LastDaily = last.Time
+			user.DailyStreak = max(last.Streak, 1)
+		}
+	}
+
+	var opening []types.LedgerEntry
+	now := time.Now()
+	for username, user := range db.users {
+		if _, entries := db.ledger.Replay(username); entries > 0 || user.Points == 0 {
+			continue
+		}
+		opening = append(opening, types.LedgerEntry{
+			Time:     now,
+			Username: username,
+			Delta:    user.Points,
+			Balance:  user.Points,
+			Reason:   types.ReasonOpening,
+		})
+	}
+
+	if len(opening) == 0 {
+		return nil
+	}
+
+	if err := db.ledger.Append(opening...); err != nil {
+		return fmt.Errorf("failed to record opening balances: %w", err)
+	}
+	log.Printf("Recorded opening balances for %d users in the ledger", len(opening))
+	return nil
+}
+
+func validateGamble(wager int, format string, winOdds float64) error {
+	if winOdds < 0 || winOdds > 1 {
+		return fmt.Errorf("win odds must be between 0 and 1")
+	}
+
+	if format != "points" && format != "percent" && format != "all" {
+		return fmt.Errorf("format must be 'points', 'percent', or 'all'")
 	}
 
-	log.Printf("Successfully loaded %d users from %s", len(users), db.path)
+	// A negative wager would turn a loss into a gain.
+	if format != "all" && wager <= 0 {
+		return fmt.Errorf("wager must be positive")
+	}
 	return nil
 }
+
+// resolveWager turns a wager request into an amount of points. A non-empty
+// second value is the outcome to report instead of gambling.
+func resolveWager(currentPoints, wager int, format string) (int, string) {
+	if currentPoints == 0 {
+		return 0, "no points"
+	}
+
+	switch format {
+	case "points":
+		if currentPoints < wager {
+			return 0, "not enough points"
+		}
+		return wager, ""
+
+	case "percent":
+		if wager > 100 {
+			return 0, "invalid percent"
+		}
+		actualWager := int(float64(currentPoints) * float64(wager) / 100.0)
+		if actualWager == 0 || currentPoints < actualWager {
+			return 0, "not enough points"
+		}
+		return actualWager, ""
+	}
+
+	return currentPoints, ""
+}
+
+func applyDailyClaim(user *types.UserData, now time.Time, config types.DailyConfig) types.DailyClaim {
+	period, next := DailyPeriod(now, config)
+	claim := types.DailyClaim{
+		Streak:    user.DailyStreak,
+		Balance:   user.Points,
+		NextClaim: next,
+	}
+
+	if !user.LastDaily.IsZero() {
+		lastPeriod, _ := DailyPeriod(user.LastDaily, config)
+		if lastPeriod >= period {
+			return claim
+		}
+		if lastPeriod == period-1 {
+			claim.Streak++
+		} else {
+			claim.Streak = 1
+		}
+	} else {
+		claim.Streak = 1
+	}
+
+	claim.Claimed = true
+	claim.Amount = config.Amount
+	claim.Bonus = DailyStreakBonus(claim.Streak, config)
+
+	user.Points += claim.Amount + claim.Bonus
+	user.LastDaily = now
+	user.DailyStreak = claim.Streak
+	claim.Balance = user.Points
+
+	log.Printf("Daily claim: %s received %d+%d points (streak %d, new balance: %d)",
+		user.Username, claim.Amount, claim.Bonus, claim.Streak, user.Points)
+	return claim
+}
@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"twitchgo/types"
+)
+
+var pointsBackends = []struct {
+	name string
+	open func(t *testing.T) types.PointsDatabase
+}{
+	{"json", func(t *testing.T) types.PointsDatabase {
+		dir := t.TempDir()
+		db, err := NewInMemoryPointsDB(filepath.Join(dir, "user_data.json"), filepath.Join(dir, "ledger.jsonl"), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}},
+	{"sqlite", func(t *testing.T) types.PointsDatabase {
+		dir := t.TempDir()
+		db, err := NewSQLitePointsDB(filepath.Join(dir, "points.db"), filepath.Join(dir, "user_data.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}},
+}
+
+// TestPointsConcurrentStress gambles and transfers from many goroutines at
+// once. Balances must never go negative, transfers must move points without
+// creating any, and every balance must match its ledger.
+func TestPointsConcurrentStress(t *testing.T) {
+	const (
+		users     = 8
+		workers   = 16
+		perWorker = 150
+		opening   = 1000
+	)
+
+	for _, backend := range pointsBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			db := backend.open(t)
+			defer db.Close()
+
+			names := make([]string, users)
+			for i := range names {
+				names[i] = fmt.Sprintf("user%d", i)
+				if err := db.AddPoints(names[i], opening, types.ReasonAdmin); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var (
+				wg        sync.WaitGroup
+				netMutex  sync.Mutex
+				gambleNet int
+			)
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(seed int64) {
+					defer wg.Done()
+					rng := rand.New(rand.NewSource(seed))
+
+					for i := 0; i < perWorker; i++ {
+						user := names[rng.Intn(users)]
+						switch rng.Intn(4) {
+						case 0, 1:
+							format, wager := "points", 1+rng.Intn(300)
+							if rng.Intn(2) == 0 {
+								format, wager = "percent", 1+rng.Intn(100)
+							}
+							outcome, _, delta, err := db.Gamble(user, wager, format, 0.5)
+							if err != nil {
+								t.Error(err)
+								return
+							}
+							netMutex.Lock()
+							switch outcome {
+							case "win":
+								gambleNet += delta
+							case "lose":
+								gambleNet -= delta
+							}
+							netMutex.Unlock()
+						default:
+							receiver := names[rng.Intn(users)]
+							err := db.TransferPoints(user, receiver, 1+rng.Intn(400))
+							if err != nil && err != types.ErrInsufficientPoints && err != types.ErrSelfTransfer {
+								t.Error(err)
+								return
+							}
+						}
+
+						if points := db.GetPoints(user); points < 0 {
+							t.Errorf("%s has %d points", user, points)
+							return
+						}
+					}
+				}(int64(w))
+			}
+			wg.Wait()
+
+			total := 0
+			for _, name := range names {
+				points := db.GetPoints(name)
+				if points < 0 {
+					t.Errorf("%s ended with %d points", name, points)
+				}
+				total += points
+
+				audit, err := db.AuditUser(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !audit.Consistent() {
+					t.Errorf("%s: balance %d, ledger replays to %d over %d entries",
+						name, audit.Balance, audit.Replayed, audit.Entries)
+				}
+			}
+
+			if want := users*opening + gambleNet; total != want {
+				t.Errorf("total points = %d, want %d (opening %d, gamble net %d)",
+					total, want, users*opening, gambleNet)
+			}
+		})
+	}
+}
+
+func TestPointsRejectsBadWagers(t *testing.T) {
+	for _, backend := range pointsBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			db := backend.open(t)
+			defer db.Close()
+
+			if err := db.AddPoints("viewer", 100, types.ReasonAdmin); err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				wager  int
+				format string
+				odds   float64
+			}{
+				{-50, "points", 0.5},
+				{0, "points", 0.5},
+				{-10, "percent", 0.5},
+				{10, "points", 1.5},
+				{10, "coins", 0.5},
+			}
+			for _, tt := range tests {
+				if _, _, _, err := db.Gamble("viewer", tt.wager, tt.format, tt.odds); err == nil {
+					t.Errorf("Gamble(%d, %q, %g) succeeded", tt.wager, tt.format, tt.odds)
+				}
+			}
+
+			if points := db.GetPoints("viewer"); points != 100 {
+				t.Errorf("balance = %d after rejected wagers, want 100", points)
+			}
+		})
+	}
+}
+
+func TestPointsTransferRules(t *testing.T) {
+	for _, backend := range pointsBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			db := backend.open(t)
+			defer db.Close()
+
+			if err := db.AddPoints("alice", 50, types.ReasonAdmin); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := db.TransferPoints("alice", "bob", 51); err != types.ErrInsufficientPoints {
+				t.Errorf("overdraft transfer: err = %v, want %v", err, types.ErrInsufficientPoints)
+			}
+			if err := db.TransferPoints("alice", "Alice", 10); err != types.ErrSelfTransfer {
+				t.Errorf("self transfer: err = %v, want %v", err, types.ErrSelfTransfer)
+			}
+			if err := db.TransferPoints("alice", "bob", 0); err == nil {
+				t.Error("zero transfer succeeded")
+			}
+			if err := db.TransferPoints("alice", "bob", 50); err != nil {
+				t.Fatal(err)
+			}
+
+			if a, b := db.GetPoints("alice"), db.GetPoints("bob"); a != 0 || b != 50 {
+				t.Errorf("balances = %d, %d, want 0, 50", a, b)
+			}
+		})
+	}
+}
+
+func TestDailyClaimSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user_data.json")
+	ledgerPath := filepath.Join(dir, "ledger.jsonl")
+	config := types.DailyConfig{Amount: 50, StreakBonus: 10, Period: 24 * time.Hour, Location: time.UTC}
+	now := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	open := func() *InMemoryPointsDB {
+		t.Helper()
+		db, err := NewInMemoryPointsDB(path, ledgerPath, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+
+	db := open()
+	if claim, err := db.ClaimDaily("viewer", now, config); err != nil || !claim.Claimed {
+		t.Fatalf("first claim: %+v, %v", claim, err)
+	}
+	if err := db.SaveToFile(); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second day's claim reaches the ledger, then the bot crashes
+	// before the snapshot is written.
+	if claim, err := db.ClaimDaily("viewer", now.Add(24*time.Hour), config); err != nil || claim.Streak != 2 {
+		t.Fatalf("second claim: %+v, %v", claim, err)
+	}
+	db.Close()
+	if err := os.WriteFile(path, stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	db = open()
+	defer db.Close()
+
+	if points := db.GetPoints("viewer"); points != 110 {
+		t.Fatalf("balance after restart = %d, want 110", points)
+	}
+	if claim, err := db.ClaimDaily("viewer", now.Add(25*time.Hour), config); err != nil || claim.Claimed {
+		t.Fatalf("claimed twice in one day after restart: %+v, %v", claim, err)
+	}
+	claim, err := db.ClaimDaily("viewer", now.Add(48*time.Hour), config)
+	if err != nil || !claim.Claimed || claim.Streak != 3 {
+		t.Fatalf("next day's claim: %+v, %v, want streak 3", claim, err)
+	}
+}
+
+// TestDailyStreakAfterReplayedGap restores claims on days 2 and 4 from the
+// ledger. The missed day 3 breaks the streak, so day 5 continues from 1.
+func TestDailyStreakAfterReplayedGap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "user_data.json")
+	ledgerPath := filepath.Join(dir, "ledger.jsonl")
+	config := types.DailyConfig{Amount: 50, StreakBonus: 10, MaxStreakBonus: 100, Period: 24 * time.Hour, Location: time.UTC}
+	day := func(n int) time.Time {
+		return time.Date(2025, time.January, n, 12, 0, 0, 0, time.UTC)
+	}
+
+	db, err := NewInMemoryPointsDB(path, ledgerPath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ClaimDaily("viewer", day(1), config); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveToFile(); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{2, 4} {
+		if _, err := db.ClaimDaily("viewer", day(n), config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+	if err := os.WriteFile(path, stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = NewInMemoryPointsDB(path, ledgerPath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	claim, err := db.ClaimDaily("viewer", day(5), config)
+	if err != nil || !claim.Claimed || claim.Streak != 2 || claim.Bonus != 10 {
+		t.Fatalf("day 5 claim: %+v, %v, want streak 2 with a 10 point bonus", claim, err)
+	}
+}
+
+func TestPointsRankUnknownUser(t *testing.T) {
+	for _, backend := range pointsBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			db := backend.open(t)
+			defer db.Close()
+
+			if err := db.AddPoints("alice", 100, types.ReasonAdmin); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.AddPoints("bob", 50, types.ReasonAdmin); err != nil {
+				t.Fatal(err)
+			}
+
+			if points, loss := db.GetRank("Estranho"); points != 3 || loss != 1 {
+				t.Errorf("GetRank(unknown) = %d, %d; want 3, 1", points, loss)
+			}
+			if points, _ := db.GetRank("bob"); points != 2 {
+				t.Errorf("GetRank(bob) = %d, want 2", points)
+			}
+		})
+	}
+}
@@ -0,0 +1,509 @@
+package utils
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"twitchgo/types"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS users (
+	username     TEXT PRIMARY KEY,
+	points       INTEGER NOT NULL DEFAULT 0,
+	gamble_loss  INTEGER NOT NULL DEFAULT 0,
+	last_daily   INTEGER NOT NULL DEFAULT 0,
+	daily_streak INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS users_points ON users (points DESC);
+CREATE INDEX IF NOT EXISTS users_gamble_loss ON users (gamble_loss DESC);
+CREATE TABLE IF NOT EXISTS ledger (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	time         INTEGER NOT NULL,
+	username     TEXT NOT NULL,
+	delta        INTEGER NOT NULL,
+	balance      INTEGER NOT NULL,
+	reason       TEXT NOT NULL,
+	counterparty TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS ledger_username ON ledger (username, id);
+`
+
+type SQLitePointsDB struct {
+	db       *sql.DB
+	rngMutex sync.Mutex
+	rng      *rand.Rand
+	path     string
+	jsonPath string
+}
+
+func NewSQLitePointsDB(path, jsonPath string) (*SQLitePointsDB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)&_pragma=busy_timeout(5000)", path)
+	conn, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open points database: %w", err)
+	}
+	// A single connection serializes writers and keeps transactions simple.
+	conn.SetMaxOpenConns(1)
+
+	if _, err := conn.Exec(sqliteSchema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create points schema: %w", err)
+	}
+
+	db := &SQLitePointsDB{
+		db:       conn,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		path:     path,
+		jsonPath: jsonPath,
+	}
+
+	if err := db.LoadFromFile(); err != nil {
+		log.Printf("Failed to migrate user data from %s: %v", jsonPath, err)
+	}
+
+	if err := db.recordOpeningBalances(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (db *SQLitePointsDB) ValidateUser(username string) error {
+	_, err := db.db.Exec(`INSERT OR IGNORE INTO users (username) VALUES (?)`, strings.ToLower(username))
+	if err != nil {
+		return fmt.Errorf("failed to create user %s: %w", username, err)
+	}
+	return nil
+}
+
+func (db *SQLitePointsDB) GetPoints(username string) int {
+	var points int
+	err := db.db.QueryRow(`SELECT points FROM users WHERE username = ?`, strings.ToLower(username)).Scan(&points)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to read points for %s: %v", username, err)
+	}
+	return points
+}
+
+func (db *SQLitePointsDB) AddPoints(username string, amount int, reason types.Reason) error {
+	if amount < 0 {
+		return fmt.Errorf("cannot add negative points")
+	}
+
+	return db.update(username, reason, func(user *types.UserData) error {
+		user.Points += amount
+		log.Printf("Added %d points to %s (new balance: %d)", amount, user.Username, user.Points)
+		return nil
+	})
+}
+
+func (db *SQLitePointsDB) SubtractPoints(username string, amount int, reason types.Reason) error {
+	if amount < 0 {
+		return fmt.Errorf("cannot subtract negative points")
+	}
+
+	return db.update(username, reason, func(user *types.UserData) error {
+		user.Points -= amount
+		if user.Points < 0 {
+			user.Points = 0
+			log.Printf("Warning: %s would have negative points, setting to 0", user.Username)
+		}
+		log.Printf("Subtracted %d points from %s (new balance: %d)", amount, user.Username, user.Points)
+		return nil
+	})
+}
+
+func (db *SQLitePointsDB) GetGambleLoss(username string) int {
+	var loss int
+	err := db.db.QueryRow(`SELECT gamble_loss FROM users WHERE username = ?`, strings.ToLower(username)).Scan(&loss)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to read gamble loss for %s: %v", username, err)
+	}
+	return loss
+}
+
+func (db *SQLitePointsDB) AddGambleLoss(username string, amount int) error {
+	if amount < 0 {
+		return fmt.Errorf("cannot add negative gamble loss")
+	}
+
+	return db.update(username, types.ReasonGamble, func(user *types.UserData) error {
+		user.GambleLoss += amount
+		log.Printf("Added %d gamble loss to %s (total loss: %d)", amount, user.Username, user.GambleLoss)
+		return nil
+	})
+}
+
+func (db *SQLitePointsDB) TransferPoints(sender, receiver string, amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("transfer amount must be positive")
+	}
+
+	sender = strings.ToLower(sender)
+	receiver = strings.ToLower(receiver)
+
+	if sender == receiver {
+		return types.ErrSelfTransfer
+	}
+
+	return db.withTx(func(tx *sql.Tx) error {
+		from, err := loadUser(tx, sender)
+		if err != nil {
+			return err
+		}
+		to, err := loadUser(tx, receiver)
+		if err != nil {
+			return err
+		}
+
+		if from.Points < amount {
+			return types.ErrInsufficientPoints
+		}
+
+		from.Points -= amount
+		to.Points += amount
+
+		now := time.Now()
+		if err := storeUser(tx, from); err != nil {
+			return err
+		}
+		if err := appendLedger(tx, now, from, -amount, types.ReasonTransfer, receiver); err != nil {
+			return err
+		}
+		if err := storeUser(tx, to); err != nil {
+			return err
+		}
+		if err := appendLedger(tx, now, to, amount, types.ReasonTransfer, sender); err != nil {
+			return err
+		}
+
+		log.Printf("Transferred %d points from %s to %s", amount, sender, receiver)
+		return nil
+	})
+}
+
+func (db *SQLitePointsDB) Gamble(username string, wager int, format string, winOdds float64) (string, int, int, error) {
+	if err := validateGamble(wager, format, winOdds); err != nil {
+		return "", 0, 0, err
+	}
+
+	var outcome string
+	var newBalance, actualWager int
+
+	err := db.update(username, types.ReasonGamble, func(user *types.UserData) error {
+		newBalance = user.Points
+
+		var rejection string
+		actualWager, rejection = resolveWager(user.Points, wager, format)
+		if rejection != "" {
+			outcome = rejection
+			actualWager = 0
+			return nil
+		}
+
+		previous := user.Points
+		if db.roll() < winOdds {
+			outcome = "win"
+			user.Points += actualWager
+			log.Printf("Gamble result: %s won %d points (balance: %d -> %d)", user.Username, actualWager, previous, user.Points)
+		} else {
+			outcome = "lose"
+			user.Points -= actualWager
+			user.GambleLoss += actualWager
+			log.Printf("Gamble result: %s lost %d points (balance: %d -> %d)", user.Username, actualWager, previous, user.Points)
+		}
+
+		newBalance = user.Points
+		return nil
+	})
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	return outcome, newBalance, actualWager, nil
+}
+
+func (db *SQLitePointsDB) ClaimDaily(username string, now time.Time, config types.DailyConfig) (types.DailyClaim, error) {
+	if config.Amount < 0 {
+		return types.DailyClaim{}, fmt.Errorf("daily amount must not be negative")
+	}
+
+	var claim types.DailyClaim
+	err := db.update(username, types.ReasonDaily, func(user *types.UserData) error {
+		claim = applyDailyClaim(user, now, config)
+		return nil
+	})
+	return claim, err
+}
+
+func (db *SQLitePointsDB) GetTopPoints(limit int) ([]string, []int) {
+	return db.top(`SELECT username, points FROM users ORDER BY points DESC, username LIMIT ?`, limit)
+}
+
+func (db *SQLitePointsDB) GetTopGambleLoss(limit int) ([]string, []int) {
+	return db.top(`SELECT username, gamble_loss FROM users ORDER BY gamble_loss DESC, username LIMIT ?`, limit)
+}
+
+func (db *SQLitePointsDB) GetRank(username string) (int, int) {
+	var pointsRank, lossRank int
+	err := db.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE points > COALESCE((SELECT points FROM users WHERE username = ?1), 0)) + 1,
+			(SELECT COUNT(*) FROM users WHERE gamble_loss > COALESCE((SELECT gamble_loss FROM users WHERE username = ?1), 0)) + 1`,
+		strings.ToLower(username)).Scan(&pointsRank, &lossRank)
+	if err != nil {
+		log.Printf("Failed to compute rank for %s: %v", username, err)
+		return 0, 0
+	}
+	return pointsRank, lossRank
+}
+
+func (db *SQLitePointsDB) GetHistory(username string, limit int) []types.LedgerEntry {
+	rows, err := db.db.Query(`
+		SELECT id, time, username, delta, balance, reason, counterparty
+		FROM ledger WHERE username = ? ORDER BY id DESC LIMIT ?`,
+		strings.ToLower(username), limit)
+	if err != nil {
+		log.Printf("Failed to query ledger for %s: %v", username, err)
+		return nil
+	}
+	defer rows.Close()
+
+	var history []types.LedgerEntry
+	for rows.Next() {
+		var entry types.LedgerEntry
+		var at int64
+		if err := rows.Scan(&entry.ID, &at, &entry.Username, &entry.Delta, &entry.Balance, &entry.Reason, &entry.Counterparty); err != nil {
+			log.Printf("Failed to read ledger row: %v", err)
+			return nil
+		}
+		entry.Time = time.Unix(0, at)
+		history = append(history, entry)
+	}
+
+	return history
+}
+
+func (db *SQLitePointsDB) AuditUser(username string) (types.LedgerAudit, error) {
+	audit := types.LedgerAudit{Username: strings.ToLower(username)}
+
+	err := db.db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM ledger WHERE username = ?1),
+			(SELECT COALESCE(SUM(delta), 0) FROM ledger WHERE username = ?1),
+			COALESCE((SELECT points FROM users WHERE username = ?1), 0)`,
+		audit.Username).Scan(&audit.Entries, &audit.Replayed, &audit.Balance)
+	if err != nil {
+		return audit, fmt.Errorf("failed to audit %s: %w", username, err)
+	}
+
+	return audit, nil
+}
+
+// SaveToFile is a no-op: every mutation is committed when it happens.
+func (db *SQLitePointsDB) SaveToFile() error {
+	return nil
+}
+
+// LoadFromFile imports the legacy JSON user data into an empty database.
+func (db *SQLitePointsDB) LoadFromFile() error {
+	var count int
+	if err := db.db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count users: %w", err)
+	}
+	if count > 0 || db.jsonPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(db.jsonPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open user data file: %w", err)
+	}
+
+	var users []types.UserData
+	if err := json.Unmarshal(data, &users); err != nil {
+		return fmt.Errorf("failed to decode user data: %w", err)
+	}
+
+	err = db.withTx(func(tx *sql.Tx) error {
+		for _, user := range users {
+			user.Username = strings.ToLower(user.Username)
+			if err := storeUser(tx, &user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Migrated %d users from %s to %s", len(users), db.jsonPath, db.path)
+	return nil
+}
+
+func (db *SQLitePointsDB) Close() error {
+	return db.db.Close()
+}
+
+func (db *SQLitePointsDB) roll() float64 {
+	db.rngMutex.Lock()
+	defer db.rngMutex.Unlock()
+	return db.rng.Float64()
+}
+
+func (db *SQLitePointsDB) top(query string, limit int) ([]string, []int) {
+	rows, err := db.db.Query(query, limit)
+	if err != nil {
+		log.Printf("Failed to query leaderboard: %v", err)
+		return nil, nil
+	}
+	defer rows.Close()
+
+	var usernames []string
+	var values []int
+	for rows.Next() {
+		var username string
+		var value int
+		if err := rows.Scan(&username, &value); err != nil {
+			log.Printf("Failed to read leaderboard row: %v", err)
+			return nil, nil
+		}
+		usernames = append(usernames, username)
+		values = append(values, value)
+	}
+
+	return usernames, values
+}
+
+// update runs fn on the user's record inside a transaction and records any
+// change in balance in the ledger under reason.
+func (db *SQLitePointsDB) update(username string, reason types.Reason, fn func(user *types.UserData) error) error {
+	return db.withTx(func(tx *sql.Tx) error {
+		user, err := loadUser(tx, strings.ToLower(username))
+		if err != nil {
+			return err
+		}
+
+		previous := user.Points
+		if err := fn(user); err != nil {
+			return err
+		}
+
+		if err := storeUser(tx, user); err != nil {
+			return err
+		}
+		if user.Points == previous {
+			return nil
+		}
+		return appendLedger(tx, time.Now(), user, user.Points-previous, reason, "")
+	})
+}
+
+// recordOpeningBalances gives every balance the ledger has never seen an
+// opening entry, so balances can always be replayed from the ledger.
+func (db *SQLitePointsDB) recordOpeningBalances() error {
+	result, err := db.db.Exec(`
+		INSERT INTO ledger (time, username, delta, balance, reason)
+		SELECT ?, username, points, points, ?
+		FROM users
+		WHERE points != 0 AND username NOT IN (SELECT DISTINCT username FROM ledger)`,
+		time.Now().UnixNano(), types.ReasonOpening)
+	if err != nil {
+		return fmt.Errorf("failed to record opening balances: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n > 0 {
+		log.Printf("Recorded opening balances for %d users in the ledger", n)
+	}
+	return nil
+}
+
+func (db *SQLitePointsDB) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func loadUser(tx *sql.Tx, username string) (*types.UserData, error) {
+	user := &types.UserData{Username: username}
+	var lastDaily int64
+
+	err := tx.QueryRow(`SELECT points, gamble_loss, last_daily, daily_streak FROM users WHERE username = ?`, username).
+		Scan(&user.Points, &user.GambleLoss, &lastDaily, &user.DailyStreak)
+	if errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Created new user: %s", username)
+		return user, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load user %s: %w", username, err)
+	}
+
+	if lastDaily != 0 {
+		user.LastDaily = time.Unix(0, lastDaily)
+	}
+	return user, nil
+}
+
+func appendLedger(tx *sql.Tx, now time.Time, user *types.UserData, delta int, reason types.Reason, counterparty string) error {
+	_, err := tx.Exec(`
+		INSERT INTO ledger (time, username, delta, balance, reason, counterparty)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		now.UnixNano(), user.Username, delta, user.Points, reason, counterparty)
+	if err != nil {
+		return fmt.Errorf("failed to append ledger entry for %s: %w", user.Username, err)
+	}
+	return nil
+}
+
+func storeUser(tx *sql.Tx, user *types.UserData) error {
+	var lastDaily int64
+	if !user.LastDaily.IsZero() {
+		lastDaily = user.LastDaily.UnixNano()
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO users (username, points, gamble_loss, last_daily, daily_streak)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (username) DO UPDATE SET
+			points = excluded.points,
+			gamble_loss = excluded.gamble_loss,
+			last_daily = excluded.last_daily,
+			daily_streak = excluded.daily_streak`,
+		user.Username, user.Points, user.GambleLoss, lastDaily, user.DailyStreak)
+	if err != nil {
+		return fmt.Errorf("failed to store user %s: %w", user.Username, err)
+	}
+	return nil
+}
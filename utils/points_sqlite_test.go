@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"twitchgo/types"
+)
+
+// TestSQLiteMigratesJSON opens the SQLite backend next to an existing
+// user_data.json. The users come over with an opening ledger entry each,
+// and only on the first open.
+func TestSQLiteMigratesJSON(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "user_data.json")
+	dbPath := filepath.Join(dir, "points.db")
+	lastDaily := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	writeUsers := func(users []types.UserData) {
+		t.Helper()
+		if err := WriteFileAtomic(jsonPath, mustJSON(t, users), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeUsers([]types.UserData{
+		{Username: "Alice", Points: 120, GambleLoss: 30, LastDaily: lastDaily, DailyStreak: 4},
+		{Username: "bob", Points: 45},
+	})
+
+	db, err := NewSQLitePointsDB(dbPath, jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for user, want := range map[string]int{"alice": 120, "ALICE": 120, "bob": 45} {
+		if got := db.GetPoints(user); got != want {
+			t.Errorf("GetPoints(%s) = %d, want %d", user, got, want)
+		}
+	}
+	if names, losses := db.GetTopGambleLoss(5); len(names) == 0 || names[0] != "alice" || losses[0] != 30 {
+		t.Errorf("GetTopGambleLoss = %q, %v; want alice with 30 first", names, losses)
+	}
+
+	// The claim date and streak came along: the same day is refused and
+	// the next one continues the streak.
+	config := types.DailyConfig{Amount: 50, StreakBonus: 10, MaxStreakBonus: 100, Period: 24 * time.Hour, Location: time.UTC}
+	if claim, err := db.ClaimDaily("alice", lastDaily.Add(time.Hour), config); err != nil || claim.Claimed {
+		t.Errorf("claim on the migrated claim's day: %+v, %v", claim, err)
+	}
+
+	for user, want := range map[string]int{"alice": 120, "bob": 45} {
+		history := db.GetHistory(user, 10)
+		if len(history) != 1 || history[0].Reason != types.ReasonOpening || history[0].Delta != want || history[0].Balance != want {
+			t.Errorf("%s history = %+v, want one opening entry of %d", user, history, want)
+		}
+		if audit, err := db.AuditUser(user); err != nil || !audit.Consistent() {
+			t.Errorf("%s audit = %+v, %v", user, audit, err)
+		}
+	}
+
+	if claim, err := db.ClaimDaily("alice", lastDaily.Add(24*time.Hour), config); err != nil || claim.Streak != 5 {
+		t.Errorf("next day's claim: %+v, %v, want streak 5", claim, err)
+	}
+	db.Close()
+
+	// A JSON file changed after the migration is not read again.
+	writeUsers([]types.UserData{{Username: "alice", Points: 9999}, {Username: "carol", Points: 10}})
+
+	db, err = NewSQLitePointsDB(dbPath, jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if got := db.GetPoints("alice"); got != 120+50+40 {
+		t.Errorf("alice has %d points after reopening, want 210", got)
+	}
+	if got := db.GetPoints("carol"); got != 0 {
+		t.Errorf("carol was migrated on the second open with %d points", got)
+	}
+	if history := db.GetHistory("bob", 10); len(history) != 1 {
+		t.Errorf("bob has %d ledger entries after reopening, want 1", len(history))
+	}
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+)
+
+// rotationWindow is the share of a pool that must be drawn before an item
+// can come up again.
+const rotationWindow = 0.75
+
+const maxRotationHistory = 5000
+
+// Rotation remembers which items were drawn most recently so random picks
+// don't repeat until most of the pool has been used. The history is written
+// to disk after every draw.
+type Rotation struct {
+	mutex  sync.Mutex
+	path   string
+	recent []string // least recently drawn first
+}
+
+type rotationFile struct {
+	Recent []string `json:"recent"`
+}
+
+func NewRotation(path string) *Rotation {
+	r := &Rotation{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r
+	}
+	if err == nil {
+		var file rotationFile
+		if err = json.Unmarshal(data, &file); err == nil {
+			r.recent = file.Recent
+			return r
+		}
+	}
+
+	log.Printf("Failed to load rotation history from %s, starting fresh: %v", path, err)
+	return r
+}
+
+// Pick returns the index in ids of the item to draw next and records it.
+// ids may be a subset of everything the rotation has seen, such as a single
+// category.
+func (r *Rotation) Pick(rng *rand.Rand, ids []string) int {
+	if len(ids) == 0 {
+		return -1
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	inPool := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inPool[id] = true
+	}
+
+	// Block the most recent draws from this pool, newest first.
+	window := int(float64(len(ids)) * rotationWindow)
+	if window >= len(ids) {
+		window = len(ids) - 1
+	}
+	blocked := make(map[string]bool, window)
+	for i := len(r.recent) - 1; i >= 0 && len(blocked) < window; i-- {
+		if inPool[r.recent[i]] {
+			blocked[r.recent[i]] = true
+		}
+	}
+
+	eligible := make([]int, 0, len(ids)-len(blocked))
+	for i, id := range ids {
+		if !blocked[id] {
+			eligible = append(eligible, i)
+		}
+	}
+
+	// Only possible when ids has duplicates.
+	if len(eligible) == 0 {
+		for i := range ids {
+			eligible = append(eligible, i)
+		}
+	}
+
+	index := eligible[rng.Intn(len(eligible))]
+	r.record(ids[index])
+	return index
+}
+
+func (r *Rotation) record(id string) {
+	for i, seen := range r.recent {
+		if seen == id {
+			r.recent = append(r.recent[:i], r.recent[i+1:]...)
+			break
+		}
+	}
+	r.recent = append(r.recent, id)
+	if len(r.recent) > maxRotationHistory {
+		r.recent = r.recent[len(r.recent)-maxRotationHistory:]
+	}
+
+	if err := r.save(); err != nil {
+		log.Printf("Failed to save rotation history: %v", err)
+	}
+}
+
+func (r *Rotation) save() error {
+	data, err := json.Marshal(rotationFile{Recent: r.recent})
+	if err != nil {
+		return fmt.Errorf("failed to encode rotation history: %w", err)
+	}
+	return WriteFileAtomic(r.path, data, 0)
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func rotationIDs(prefix string, n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s%02d", prefix, i)
+	}
+	return ids
+}
+
+// checkWindow fails if any draw repeats one of the window draws before it.
+func checkWindow(t *testing.T, draws []string, window int) {
+	t.Helper()
+	for i, id := range draws {
+		for j := max(0, i-window); j < i; j++ {
+			if draws[j] == id {
+				t.Fatalf("draw %d repeats %s from draw %d, inside the window of %d", i, id, j, window)
+			}
+		}
+	}
+}
+
+func TestRotationWindow(t *testing.T) {
+	tests := []struct {
+		size   int
+		window int
+	}{
+		{20, 15},
+		{4, 3},
+		{3, 2},
+		// The window never blocks the whole pool.
+		{2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.size), func(t *testing.T) {
+			rotation := NewRotation(filepath.Join(t.TempDir(), "history.json"))
+			rng := rand.New(rand.NewSource(1))
+			ids := rotationIDs("q", tt.size)
+
+			var draws []string
+			seen := make(map[string]bool)
+			for i := 0; i < 200; i++ {
+				id := ids[rotation.Pick(rng, ids)]
+				draws = append(draws, id)
+				seen[id] = true
+			}
+
+			checkWindow(t, draws, tt.window)
+			if len(seen) != tt.size {
+				t.Errorf("drew %d of %d items", len(seen), tt.size)
+			}
+		})
+	}
+}
+
+func TestRotationSubsetPool(t *testing.T) {
+	rotation := NewRotation(filepath.Join(t.TempDir(), "history.json"))
+	rng := rand.New(rand.NewSource(2))
+	all := append(rotationIDs("a", 8), rotationIDs("b", 8)...)
+	category := all[:8]
+
+	// Draws from the whole pool don't use up the category's window.
+	for i := 0; i < 30; i++ {
+		rotation.Pick(rng, all)
+	}
+
+	var draws []string
+	for i := 0; i < 200; i++ {
+		draws = append(draws, category[rotation.Pick(rng, category)])
+	}
+	checkWindow(t, draws, 6)
+}
+
+func TestRotationSmallPools(t *testing.T) {
+	rotation := NewRotation(filepath.Join(t.TempDir(), "history.json"))
+	rng := rand.New(rand.NewSource(3))
+
+	if index := rotation.Pick(rng, nil); index != -1 {
+		t.Errorf("Pick(empty) = %d, want -1", index)
+	}
+	for i := 0; i < 3; i++ {
+		if index := rotation.Pick(rng, []string{"only"}); index != 0 {
+			t.Fatalf("Pick(one item) = %d, want 0", index)
+		}
+	}
+}
+
+func TestRotationHistorySurvivesReopening(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	ids := rotationIDs("q", 20)
+
+	rotation := NewRotation(path)
+	rng := rand.New(rand.NewSource(4))
+	var draws []string
+	for i := 0; i < 10; i++ {
+		draws = append(draws, ids[rotation.Pick(rng, ids)])
+	}
+
+	// A restart picks up where the last run left off, so the window holds
+	// across it.
+	rotation = NewRotation(path)
+	rng = rand.New(rand.NewSource(5))
+	for i := 0; i < 100; i++ {
+		draws = append(draws, ids[rotation.Pick(rng, ids)])
+	}
+	checkWindow(t, draws, 15)
+}
+
+func TestRotationCorruptHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte(`{"recent": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rotation := NewRotation(path)
+	ids := rotationIDs("q", 4)
+	if index := rotation.Pick(rand.New(rand.NewSource(6)), ids); index < 0 {
+		t.Fatal("rotation with a corrupt history drew nothing")
+	}
+
+	// The next save replaces the corrupt file.
+	if reopened := NewRotation(path); len(reopened.recent) != 1 {
+		t.Fatalf("history after one draw = %q, want one entry", reopened.recent)
+	}
+}
@@ -1,43 +1,161 @@
 package utils
 
 import (
+	"math"
 	"math/rand"
 	"strings"
-	"time"
+	"unicode"
 )
 
-type ScrambleUtils struct {
-	rng *rand.Rand
+// ScrambleOptions set how hard a scrambled word is to read.
+type ScrambleOptions struct {
+	// Shuffle is the share of each word's letters that get moved, from 0 to
+	// 1. At least two letters per word always move.
+	Shuffle float64 `yaml:"shuffle"`
+	// KeepFirst leaves the first letter of every word in place.
+	KeepFirst bool `yaml:"keep_first"`
 }
 
-func NewScrambleUtils() *ScrambleUtils {
-	return &ScrambleUtils{
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+// ScrambleWords shuffles the letters of each word in s on its own, leaving
+// spaces and punctuation where they are. It reports false when s can't be
+// scrambled into anything different, as with "aa" or "a b". If keeping the
+// first letters is what makes that impossible, they are moved anyway.
+func ScrambleWords(rng *rand.Rand, s string, opts ScrambleOptions) (string, bool) {
+	runes := []rune(s)
+	words := scrambleWordSpans(runes)
+
+	if opts.KeepFirst && !scramblable(runes, words, true) {
+		opts.KeepFirst = false
+	}
+	if !scramblable(runes, words, opts.KeepFirst) {
+		return s, false
+	}
+
+	scrambled := make([]rune, len(runes))
+	copy(scrambled, runes)
+	for _, word := range words {
+		shuffleWord(rng, scrambled, movable(word, opts.KeepFirst), opts.Shuffle)
 	}
+
+	// A partial shuffle can leave everything where it was; swapping two
+	// different letters guarantees a change.
+	if strings.EqualFold(string(scrambled), s) {
+		for _, word := range words {
+			if swapDistinct(scrambled, movable(word, opts.KeepFirst)) {
+				break
+			}
+		}
+	}
+
+	return string(scrambled), true
 }
 
-func GenerateScrambleHint(word string) string {
-	if len(word) == 0 {
-		return ""
+// CanScramble reports whether s has any word with two different letters.
+func CanScramble(s string) bool {
+	runes := []rune(s)
+	return scramblable(runes, scrambleWordSpans(runes), false)
+}
+
+// LetterCount counts the letters and digits of s, the characters a scramble
+// moves.
+func LetterCount(s string) int {
+	count := 0
+	for _, r := range s {
+		if isWordLetter(r) {
+			count++
+		}
 	}
+	return count
+}
 
-	if len(word) <= 2 {
-		return word
+// scrambleWordSpans lists the letter positions of each word in runes.
+func scrambleWordSpans(runes []rune) [][]int {
+	var words [][]int
+	var current []int
+	for i, r := range runes {
+		if isWordLetter(r) {
+			current = append(current, i)
+			continue
+		}
+		if len(current) > 0 {
+			words = append(words, current)
+			current = nil
+		}
 	}
+	if len(current) > 0 {
+		words = append(words, current)
+	}
+	return words
+}
 
-	if len(word) == 3 {
-		return string(word[0]) + "_" + string(word[2])
+func movable(word []int, keepFirst bool) []int {
+	if keepFirst && len(word) > 0 {
+		return word[1:]
 	}
+	return word
+}
 
-	hint := string(word[0])
-	for i := 1; i < len(word)-1; i++ {
-		hint += "_"
+func scramblable(runes []rune, words [][]int, keepFirst bool) bool {
+	for _, word := range words {
+		// A one-letter word has nothing to swap, and with keepFirst it has
+		// no movable letters at all.
+		positions := movable(word, keepFirst)
+		if len(positions) < 2 {
+			continue
+		}
+		for _, i := range positions[1:] {
+			if unicode.ToLower(runes[i]) != unicode.ToLower(runes[positions[0]]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// shuffleWord moves the share shuffle of positions among themselves.
+func shuffleWord(rng *rand.Rand, runes []rune, positions []int, shuffle float64) {
+	if len(positions) < 2 {
+		return
+	}
+
+	count := int(math.Ceil(float64(len(positions)) * shuffle))
+	if count < 2 {
+		count = 2
+	}
+	if count > len(positions) {
+		count = len(positions)
 	}
-	hint += string(word[len(word)-1])
 
-	return hint
+	picked := make([]int, len(positions))
+	copy(picked, positions)
+	rng.Shuffle(len(picked), func(i, j int) {
+		picked[i], picked[j] = picked[j], picked[i]
+	})
+	picked = picked[:count]
+
+	letters := make([]rune, count)
+	for i, position := range picked {
+		letters[i] = runes[position]
+	}
+	// Rotating after the shuffle moves every picked letter to another
+	// picked position.
+	for i, position := range picked {
+		runes[position] = letters[(i+1)%count]
+	}
 }
 
-func ValidateScrambledWord(original, scrambled string) bool {
-	return !strings.EqualFold(original, scrambled)
+func swapDistinct(runes []rune, positions []int) bool {
+	if len(positions) < 2 {
+		return false
+	}
+
+	for _, i := range positions {
+		for _, j := range positions {
+			if unicode.ToLower(runes[i]) != unicode.ToLower(runes[j]) {
+				runes[i], runes[j] = runes[j], runes[i]
+				return true
+			}
+		}
+	}
+	return false
 }
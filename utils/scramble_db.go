@@ -8,19 +8,31 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"twitchgo/types"
 )
 
+// ScrambleWordsFile is where the word pool is loaded from and saved to.
+var ScrambleWordsFile = filepath.Join("data", "scramble_words.json")
+
+// ScrambleHistoryFile keeps the word rotation across restarts.
+var ScrambleHistoryFile = filepath.Join("data", "scramble_history.json")
+
+const scrambleBackups = 3
+
 type InMemoryScrambleDB struct {
-	words []types.ScrambleWord
-	rng   *rand.Rand
+	mutex    sync.Mutex
+	words    []types.ScrambleWord
+	rng      *rand.Rand
+	rotation *Rotation
 }
 
 func NewInMemoryScrambleDB() *InMemoryScrambleDB {
 	db := &InMemoryScrambleDB{
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		rotation: NewRotation(ScrambleHistoryFile),
 	}
 
 	if err := db.ReloadWords(); err != nil {
@@ -30,10 +42,10 @@ func NewInMemoryScrambleDB() *InMemoryScrambleDB {
 	return db
 }
 
+// ReloadWords replaces the pool with ScrambleWordsFile. Disabled words are
+// kept so they can be listed and enabled again.
 func (db *InMemoryScrambleDB) ReloadWords() error {
-	dataPath := filepath.Join("data", "scramble_words.json")
-
-	file, err := os.Open(dataPath)
+	file, err := os.Open(ScrambleWordsFile)
 	if err != nil {
 		return fmt.Errorf("failed to open scramble words file: %w", err)
 	}
@@ -44,66 +56,182 @@ func (db *InMemoryScrambleDB) ReloadWords() error {
 		return fmt.Errorf("failed to decode scramble words: %w", err)
 	}
 
-	enabledWords := make([]types.ScrambleWord, 0)
-	for _, word := range words {
-		if word.Enabled {
-			enabledWords = append(enabledWords, word)
-		}
+	for i := range words {
+		normalizeWord(&words[i])
 	}
 
-	db.words = enabledWords
-	log.Printf("Loaded %d scramble words", len(db.words))
+	db.mutex.Lock()
+	db.words = words
+	enabled := len(db.getEnabledWords())
+	db.mutex.Unlock()
+
+	log.Printf("Loaded %d scramble words, %d enabled", len(words), enabled)
 
 	return nil
 }
 
 func (db *InMemoryScrambleDB) GetRandomWord() *types.ScrambleWord {
-	if len(db.words) == 0 {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	enabled := db.getEnabledWords()
+	if len(enabled) == 0 {
 		return nil
 	}
 
-	index := db.rng.Intn(len(db.words))
-	word := db.words[index]
-	return &word
+	ids := make([]string, len(enabled))
+	for i, word := range enabled {
+		ids[i] = word.ID
+		if ids[i] == "" {
+			ids[i] = word.Word
+		}
+	}
+
+	return &enabled[db.rotation.Pick(db.rng, ids)]
 }
 
-func ScrambleString(s string) string {
-	if len(s) <= 1 {
-		return s
+func (db *InMemoryScrambleDB) GetWord(idOrWord string) *types.ScrambleWord {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if i := db.find(idOrWord); i >= 0 {
+		word := db.words[i]
+		return &word
 	}
+	return nil
+}
 
-	runes := []rune(s)
-	shuffled := string(runes)
+func (db *InMemoryScrambleDB) ListWords(category string) []types.ScrambleWord {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
 
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	for shuffled == s {
-		for i := len(runes) - 1; i > 0; i-- {
-			j := rng.Intn(i + 1)
-			runes[i], runes[j] = runes[j], runes[i]
+	var words []types.ScrambleWord
+	for _, word := range db.words {
+		if category == "" || SameCategory(word.Category, category) {
+			words = append(words, word)
 		}
-		shuffled = string(runes)
+	}
+	return words
+}
+
+func (db *InMemoryScrambleDB) AddWord(word types.ScrambleWord) string {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if word.ID == "" {
+		word.ID = db.nextID()
+	}
+	normalizeWord(&word)
+	db.words = append(db.words, word)
+	return word.ID
+}
+
+func (db *InMemoryScrambleDB) EnableWord(idOrWord string) bool {
+	return db.setEnabled(idOrWord, true)
+}
+
+func (db *InMemoryScrambleDB) DisableWord(idOrWord string) bool {
+	return db.setEnabled(idOrWord, false)
+}
+
+func (db *InMemoryScrambleDB) GetWordCount() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return len(db.words)
+}
+
+func (db *InMemoryScrambleDB) GetEnabledWordCount() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return len(db.getEnabledWords())
+}
+
+func (db *InMemoryScrambleDB) SaveToJSONFile(filename string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	words := db.words
+	if words == nil {
+		words = []types.ScrambleWord{}
 	}
 
-	return shuffled
+	data, err := json.MarshalIndent(words, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(filename, append(data, '\n'), scrambleBackups); err != nil {
+		return err
+	}
+
+	log.Printf("Successfully saved %d scramble words to %s", len(words), filename)
+	return nil
 }
 
-func CheckScrambleGuess(guess, originalWord string) (bool, float64) {
-	guess = SanitizeMessage(guess)
-	originalWord = SanitizeMessage(originalWord)
+func (db *InMemoryScrambleDB) setEnabled(idOrWord string, enabled bool) bool {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
 
-	if guess == originalWord {
-		return true, 1.0
+	i := db.find(idOrWord)
+	if i < 0 {
+		return false
 	}
+	db.words[i].Enabled = enabled
+	return true
+}
 
-	if strings.Contains(guess, originalWord) {
-		return true, 1.0
+// find returns the index of the word with ID idOrWord, or else of the first
+// word spelled idOrWord, or -1.
+func (db *InMemoryScrambleDB) find(idOrWord string) int {
+	for i, word := range db.words {
+		if word.ID == idOrWord {
+			return i
+		}
+	}
+	for i, word := range db.words {
+		if strings.EqualFold(word.Word, strings.TrimSpace(idOrWord)) {
+			return i
+		}
 	}
+	return -1
+}
 
-	similarity := CalculateSimilarity(guess, originalWord)
+func (db *InMemoryScrambleDB) getEnabledWords() []types.ScrambleWord {
+	var enabled []types.ScrambleWord
+	for _, word := range db.words {
+		if word.Enabled {
+			enabled = append(enabled, word)
+		}
+	}
+	return enabled
+}
 
-	if similarity >= 0.85 {
-		return true, similarity
+// nextID numbers added words s0000000001, s0000000002 and so on.
+func (db *InMemoryScrambleDB) nextID() string {
+	highest := 0
+	for _, word := range db.words {
+		var n int
+		if _, err := fmt.Sscanf(word.ID, "s%d", &n); err == nil && n > highest {
+			highest = n
+		}
 	}
+	return fmt.Sprintf("s%010d", highest+1)
+}
 
-	return false, similarity
+// normalizeWord trims the word, canonicalizes category and difficulty, and
+// warns about words that will never be drawn.
+func normalizeWord(word *types.ScrambleWord) {
+	word.Word = strings.TrimSpace(word.Word)
+	word.Definition = strings.TrimSpace(word.Definition)
+	word.Category = strings.ToLower(strings.TrimSpace(word.Category))
+	level, ok := types.ParseDifficulty(string(word.Difficulty))
+	if !ok {
+		log.Printf("Scramble word %s has an unknown difficulty %q, using %q", word.ID, word.Difficulty, level)
+	}
+	word.Difficulty = level
+	if !CanScramble(word.Word) {
+		log.Printf("Scramble word %s (%q) can't be scrambled and will be skipped", word.ID, word.Word)
+	}
 }
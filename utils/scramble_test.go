@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sortedLetters(s string) string {
+	letters := []rune(strings.ToLower(s))
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+	return string(letters)
+}
+
+func TestScrambleWords(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts ScrambleOptions
+		ok   bool
+	}{
+		{"single word", "gaules", ScrambleOptions{Shuffle: 1}, true},
+		{"keep first", "gaules", ScrambleOptions{Shuffle: 1, KeepFirst: true}, true},
+		{"one-letter article", "O Rappa", ScrambleOptions{Shuffle: 1, KeepFirst: true}, true},
+		{"leading article", "A Fazenda", ScrambleOptions{Shuffle: 0.5, KeepFirst: true}, true},
+		{"one-letter word last", "Vitamina C", ScrambleOptions{Shuffle: 1, KeepFirst: true}, true},
+		{"two letters keep first", "ab", ScrambleOptions{Shuffle: 1, KeepFirst: true}, true},
+		{"punctuation", "pão-de-ló!", ScrambleOptions{Shuffle: 1}, true},
+		{"repeated letter", "aa", ScrambleOptions{Shuffle: 1}, false},
+		{"only one-letter words", "a b c", ScrambleOptions{Shuffle: 1, KeepFirst: true}, false},
+		{"single letter", "x", ScrambleOptions{Shuffle: 1, KeepFirst: true}, false},
+		{"empty", "", ScrambleOptions{Shuffle: 1, KeepFirst: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 200; i++ {
+				got, ok := ScrambleWords(rng, tt.in, tt.opts)
+				if ok != tt.ok {
+					t.Fatalf("ScrambleWords(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+				}
+				if !ok {
+					if got != tt.in {
+						t.Fatalf("ScrambleWords(%q) = %q, want it unchanged", tt.in, got)
+					}
+					continue
+				}
+				if strings.EqualFold(got, tt.in) {
+					t.Fatalf("ScrambleWords(%q) = %q, want it changed", tt.in, got)
+				}
+				if sortedLetters(got) != sortedLetters(tt.in) {
+					t.Fatalf("ScrambleWords(%q) = %q, want the same letters", tt.in, got)
+				}
+			}
+		})
+	}
+}
+
+func TestScrambleWordsKeepsLayout(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	in := "O Rappa, pão-de-ló!"
+	for i := 0; i < 200; i++ {
+		got, _ := ScrambleWords(rng, in, ScrambleOptions{Shuffle: 1, KeepFirst: true})
+		gotRunes, inRunes := []rune(got), []rune(in)
+		for j, r := range inRunes {
+			if !isWordLetter(r) && gotRunes[j] != r {
+				t.Fatalf("ScrambleWords(%q) = %q, moved %q", in, got, r)
+			}
+		}
+		if !strings.HasPrefix(got, "O R") {
+			t.Fatalf("ScrambleWords(%q) = %q, want first letters kept", in, got)
+		}
+	}
+}
+
+func TestCanScramble(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"gaules", true},
+		{"O Rappa", true},
+		{"ab", true},
+		{"aa", false},
+		{"a b", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := CanScramble(tt.in); got != tt.want {
+			t.Errorf("CanScramble(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
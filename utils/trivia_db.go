@@ -2,36 +2,93 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"twitchgo/types"
 )
 
+// TriviaQuestionsFile is where the trivia pool is loaded from and saved to.
+var TriviaQuestionsFile = filepath.Join("data", "trivia_questions.json")
+
+// TriviaHistoryFile keeps the question rotation across restarts.
+var TriviaHistoryFile = filepath.Join("data", "trivia_history.json")
+
+const triviaBackups = 3
+
 type InMemoryTriviaDB struct {
+	mutex     sync.Mutex
 	questions []types.TriviaQuestion
 	rng       *rand.Rand
+	rotation  *Rotation
 }
 
 func NewInMemoryTriviaDB() *InMemoryTriviaDB {
 	db := &InMemoryTriviaDB{
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		rotation: NewRotation(TriviaHistoryFile),
 	}
 	db.loadDefaultQuestions()
 	return db
 }
 
 func (db *InMemoryTriviaDB) GetRandomQuestion() *types.TriviaQuestion {
-	enabledQuestions := db.getEnabledQuestions()
-	if len(enabledQuestions) == 0 {
+	return db.GetRandomQuestionIn("")
+}
+
+func (db *InMemoryTriviaDB) GetRandomQuestionIn(category string) *types.TriviaQuestion {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	var candidates []types.TriviaQuestion
+	for _, q := range db.getEnabledQuestions() {
+		if category == "" || SameCategory(q.Category, category) {
+			candidates = append(candidates, q)
+		}
+	}
+
+	if len(candidates) == 0 {
 		return nil
 	}
-	return &enabledQuestions[db.rng.Intn(len(enabledQuestions))]
+
+	ids := make([]string, len(candidates))
+	for i, q := range candidates {
+		ids[i] = q.ID
+		if ids[i] == "" {
+			ids[i] = q.Question
+		}
+	}
+	return &candidates[db.rotation.Pick(db.rng, ids)]
+}
+
+func (db *InMemoryTriviaDB) GetCategoryCounts() map[string]int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	counts := make(map[string]int)
+	for _, q := range db.getEnabledQuestions() {
+		if q.Category != "" {
+			counts[strings.ToLower(q.Category)]++
+		}
+	}
+	return counts
+}
+
+// SameCategory compares category names ignoring case and surrounding space.
+func SameCategory(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
 }
 
 func (db *InMemoryTriviaDB) GetQuestionByID(id string) *types.TriviaQuestion {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
 	for _, q := range db.questions {
 		if q.ID == id {
 			return &q
@@ -40,11 +97,22 @@ func (db *InMemoryTriviaDB) GetQuestionByID(id string) *types.TriviaQuestion {
 	return nil
 }
 
-func (db *InMemoryTriviaDB) AddQuestion(question types.TriviaQuestion) {
+func (db *InMemoryTriviaDB) AddQuestion(question types.TriviaQuestion) string {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if question.ID == "" {
+		question.ID = db.nextID()
+	}
+	normalizeQuestion(&question)
 	db.questions = append(db.questions, question)
+	return question.ID
 }
 
 func (db *InMemoryTriviaDB) EnableQuestion(id string) bool {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
 	for i, q := range db.questions {
 		if q.ID == id {
 			db.questions[i].Enabled = true
@@ -55,6 +123,9 @@ func (db *InMemoryTriviaDB) EnableQuestion(id string) bool {
 }
 
 func (db *InMemoryTriviaDB) DisableQuestion(id string) bool {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
 	for i, q := range db.questions {
 		if q.ID == id {
 			db.questions[i].Enabled = false
@@ -65,23 +136,29 @@ func (db *InMemoryTriviaDB) DisableQuestion(id string) bool {
 }
 
 func (db *InMemoryTriviaDB) GetQuestionCount() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
 	return len(db.questions)
 }
 
 func (db *InMemoryTriviaDB) GetEnabledQuestionCount() int {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
 	return len(db.getEnabledQuestions())
 }
 
 func (db *InMemoryTriviaDB) SaveToJSONFile(filename string) error {
-	file, err := os.Create(filename)
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	data, err := json.MarshalIndent(db.questions, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(db.questions); err != nil {
+	if err := WriteFileAtomic(filename, append(data, '\n'), triviaBackups); err != nil {
 		return err
 	}
 
@@ -90,7 +167,10 @@ func (db *InMemoryTriviaDB) SaveToJSONFile(filename string) error {
 }
 
 func (db *InMemoryTriviaDB) ReloadFromFile() error {
-	return db.loadFromJSONFile("data/trivia_questions.json")
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	return db.loadFromJSONFile(TriviaQuestionsFile)
 }
 
 func (db *InMemoryTriviaDB) getEnabledQuestions() []types.TriviaQuestion {
@@ -103,8 +183,40 @@ func (db *InMemoryTriviaDB) getEnabledQuestions() []types.TriviaQuestion {
 	return enabled
 }
 
+// normalizeQuestion fills Answer for questions that only list Answers,
+// canonicalizes category and difficulty, and warns about patterns that will
+// never match.
+func normalizeQuestion(q *types.TriviaQuestion) {
+	if q.Answer == "" && len(q.Answers) > 0 {
+		q.Answer = q.Answers[0]
+	}
+	q.Category = strings.ToLower(strings.TrimSpace(q.Category))
+	level, ok := types.ParseDifficulty(string(q.Difficulty))
+	if !ok {
+		log.Printf("Trivia question %s has an unknown difficulty %q, using %q", q.ID, q.Difficulty, level)
+	}
+	q.Difficulty = level
+	for _, pattern := range q.Patterns {
+		if CompileAnswerPattern(pattern) == nil {
+			log.Printf("Trivia question %s has an invalid answer pattern: %q", q.ID, pattern)
+		}
+	}
+}
+
+// nextID continues the t0000000001 numbering of the bundled questions.
+func (db *InMemoryTriviaDB) nextID() string {
+	highest := 0
+	for _, q := range db.questions {
+		var n int
+		if _, err := fmt.Sscanf(q.ID, "t%d", &n); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return fmt.Sprintf("t%010d", highest+1)
+}
+
 func (db *InMemoryTriviaDB) loadDefaultQuestions() {
-	if err := db.loadFromJSONFile("data/trivia_questions.json"); err != nil {
+	if err := db.loadFromJSONFile(TriviaQuestionsFile); err != nil {
 		log.Printf("Failed to load trivia questions from JSON file: %v", err)
 		log.Println("Loading fallback default questions...")
 		db.loadFallbackQuestions()
@@ -124,6 +236,10 @@ func (db *InMemoryTriviaDB) loadFromJSONFile(filename string) error {
 		return err
 	}
 
+	for i := range questions {
+		normalizeQuestion(&questions[i])
+	}
+
 	db.questions = questions
 	log.Printf("Successfully loaded %d trivia questions from %s", len(questions), filename)
 	return nil
@@ -131,20 +247,20 @@ func (db *InMemoryTriviaDB) loadFromJSONFile(filename string) error {
 
 func (db *InMemoryTriviaDB) loadFallbackQuestions() {
 	db.questions = []types.TriviaQuestion{
-		{ID: "t0000000001", Question: "Qual reality show brasileiro confina participantes em uma casa e é conhecido pela sigla BBB?", Answer: "Big Brother Brasil", Enabled: true},
-		{ID: "t0000000002", Question: "Qual o nome do streamer brasileiro famoso pelo bordão 'Aí pai, para!'?", Answer: "Casimiro", Enabled: true},
-		{ID: "t0000000003", Question: "Qual o nome da personagem de novela, interpretada por Adriana Esteves, que se tornou um meme mundial com a frase 'Me serve, vadia'?", Answer: "Carminha", Enabled: true},
-		{ID: "t0000000004", Question: "Qual o nome do cantor que viralizou com a música 'Caneta Azul'?", Answer: "Manoel Gomes", Enabled: true},
-		{ID: "t0000000005", Question: "Qual o nome do podcast apresentado por Igão e Mítico, um dos mais populares do Brasil?", Answer: "Podpah", Enabled: true},
-		{ID: "t0000000006", Question: "De qual grupo de humor se originou o meme 'Nheco nheco no potinho'?", Answer: "Hermes e Renato", Enabled: true},
-		{ID: "t0000000007", Question: "Qual o nome da personagem de um vídeo viral que diz 'Meu nome é Júlia, e eu gosto de pular'?", Answer: "Júlia", Enabled: true},
-		{ID: "t0000000008", Question: "Qual o nome da cantora que viralizou com o hit 'Que Tiro Foi Esse'?", Answer: "Jojo Todynho", Enabled: true},
-		{ID: "t0000000009", Question: "O meme 'Nazaré Confusa', com a personagem olhando para os lados, veio de qual novela?", Answer: "Senhora do Destino", Enabled: true},
-		{ID: "t0000000010", Question: "Complete o bordão do vídeo viral da banda New Dingo: 'Acorda, Pedrinho, que hoje tem...'", Answer: "campeonato", Enabled: true},
-		{ID: "t0000000011", Question: "Qual o nome do youtuber que ficou famoso pelo quadro '5inco Minutos'?", Answer: "Kéfera", Enabled: true},
-		{ID: "t0000000012", Question: "Qual o nome do grupo de humor do YouTube famoso por esquetes como 'Reunião de Condomínio'?", Answer: "Porta dos Fundos", Enabled: true},
-		{ID: "t0000000013", Question: "Qual o nome do personagem de um vídeo viral que ficou conhecido como a 'Grávida de Taubaté'?", Answer: "Maria Verônica", Enabled: true},
-		{ID: "t0000000014", Question: "Qual o nome do streamer brasileiro de jogos conhecido como 'O Pai' e pelo bordão 'Respeita o F'?", Answer: "Gaules", Enabled: true},
-		{ID: "t0000000015", Question: "Qual o nome do personagem criado por um humorista baiano que ficou famoso por seus vídeos no WhatsApp e pelo bordão 'Ô, psit'?", Answer: "Dum Ice", Enabled: true},
+		{ID: "t0000000001", Question: "Qual reality show brasileiro confina participantes em uma casa e é conhecido pela sigla BBB?", Answer: "Big Brother Brasil", Category: "tv", Difficulty: types.DifficultyEasy, Enabled: true},
+		{ID: "t0000000002", Question: "Qual o nome do streamer brasileiro famoso pelo bordão 'Aí pai, para!'?", Answer: "Casimiro", Category: "streamers", Difficulty: types.DifficultyEasy, Enabled: true},
+		{ID: "t0000000003", Question: "Qual o nome da personagem de novela, interpretada por Adriana Esteves, que se tornou um meme mundial com a frase 'Me serve, vadia'?", Answer: "Carminha", Category: "tv", Difficulty: types.DifficultyMedium, Enabled: true},
+		{ID: "t0000000004", Question: "Qual o nome do cantor que viralizou com a música 'Caneta Azul'?", Answer: "Manoel Gomes", Category: "musica", Difficulty: types.DifficultyEasy, Enabled: true},
+		{ID: "t0000000005", Question: "Qual o nome do podcast apresentado por Igão e Mítico, um dos mais populares do Brasil?", Answer: "Podpah", Category: "streamers", Difficulty: types.DifficultyMedium, Enabled: true},
+		{ID: "t0000000006", Question: "De qual grupo de humor se originou o meme 'Nheco nheco no potinho'?", Answer: "Hermes e Renato", Category: "memes", Difficulty: types.DifficultyHard, Enabled: true},
+		{ID: "t0000000007", Question: "Qual o nome da personagem de um vídeo viral que diz 'Meu nome é Júlia, e eu gosto de pular'?", Answer: "Júlia", Category: "memes", Difficulty: types.DifficultyMedium, Enabled: true},
+		{ID: "t0000000008", Question: "Qual o nome da cantora que viralizou com o hit 'Que Tiro Foi Esse'?", Answer: "Jojo Todynho", Category: "musica", Difficulty: types.DifficultyEasy, Enabled: true},
+		{ID: "t0000000009", Question: "O meme 'Nazaré Confusa', com a personagem olhando para os lados, veio de qual novela?", Answer: "Senhora do Destino", Category: "tv", Difficulty: types.DifficultyMedium, Enabled: true},
+		{ID: "t0000000010", Question: "Complete o bordão do vídeo viral da banda New Dingo: 'Acorda, Pedrinho, que hoje tem...'", Answer: "campeonato", Category: "memes", Difficulty: types.DifficultyMedium, Enabled: true},
+		{ID: "t0000000011", Question: "Qual o nome do youtuber que ficou famoso pelo quadro '5inco Minutos'?", Answer: "Kéfera", Category: "streamers", Difficulty: types.DifficultyHard, Enabled: true},
+		{ID: "t0000000012", Question: "Qual o nome do grupo de humor do YouTube famoso por esquetes como 'Reunião de Condomínio'?", Answer: "Porta dos Fundos", Category: "memes", Difficulty: types.DifficultyEasy, Enabled: true},
+		{ID: "t0000000013", Question: "Qual o nome do personagem de um vídeo viral que ficou conhecido como a 'Grávida de Taubaté'?", Answer: "Maria Verônica", Category: "memes", Difficulty: types.DifficultyHard, Enabled: true},
+		{ID: "t0000000014", Question: "Qual o nome do streamer brasileiro de jogos conhecido como 'O Pai' e pelo bordão 'Respeita o F'?", Answer: "Gaules", Category: "streamers", Difficulty: types.DifficultyMedium, Enabled: true},
+		{ID: "t0000000015", Question: "Qual o nome do personagem criado por um humorista baiano que ficou famoso por seus vídeos no WhatsApp e pelo bordão 'Ô, psit'?", Answer: "Dum Ice", Category: "memes", Difficulty: types.DifficultyHard, Enabled: true},
 	}
 }